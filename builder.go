@@ -0,0 +1,211 @@
+package fasturl
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Builder constructs or edits a URL one component at a time. Values are given decoded and encoded as needed,
+// the first invalid one is reported by `Builder.URL` and `Builder.String` and every later call is ignored.
+type Builder struct {
+	u   URL
+	err error
+}
+
+// NewBuilder returns a builder starting from a copy of base, or from an empty URL when base is nil
+func NewBuilder(base *URL) *Builder {
+	b := &Builder{}
+	if base != nil {
+		b.u = *base
+	}
+	return b
+}
+
+func (b *Builder) fail(format string, args ...interface{}) *Builder {
+	if b.err == nil {
+		b.err = fmt.Errorf("fasturl: "+format, args...)
+	}
+	return b
+}
+
+// WithScheme sets the scheme, which is lower cased
+func (b *Builder) WithScheme(scheme string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	s, err := canonicalizeProtocol(scheme)
+	if err != nil {
+		return b.fail("invalid scheme %q", scheme)
+	}
+	b.u.Protocol = s
+	if s != "" && b.u.Host != "" {
+		b.u.opaque = false
+	}
+	return b
+}
+
+// WithUserinfo sets the username and password, an empty password leaves out the colon and empty both remove the userinfo
+func (b *Builder) WithUserinfo(username, password string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.u.User = percentEncode(username, userinfoEncodeSet+"%")
+	if password != "" {
+		b.u.User += ":" + percentEncode(password, userinfoEncodeSet+"%")
+	}
+	return b
+}
+
+// WithHost sets the host, internationalized names are converted to their ASCII form and IPv6 addresses are bracketed
+func (b *Builder) WithHost(host string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil && strings.Contains(host, ":") {
+		b.u.Host = "[" + strings.ToLower(strings.Trim(host, "[]")) + "]"
+		b.u.opaque = false
+		return b
+	}
+	h, err := canonicalizeHostname(host)
+	if err != nil || strings.HasPrefix(host, "[") {
+		return b.fail("invalid host %q", host)
+	}
+	b.u.Host = h
+	b.u.opaque = false
+	return b
+}
+
+// WithPort sets the port, 0 removes it
+func (b *Builder) WithPort(port int) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if port < 0 || port > 65535 {
+		return b.fail("port %d out of range", port)
+	}
+	b.u.Port = ""
+	if port != 0 {
+		b.u.Port = strconv.Itoa(port)
+	}
+	return b
+}
+
+// AppendPath adds segments to the path, each escaped so that a "/" inside one doesn't start another
+// and a "." or ".." one isn't removed as a dot-segment
+func (b *Builder) AppendPath(segments ...string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSuffix(b.u.Path, "/"))
+	for _, seg := range segments {
+		sb.WriteByte('/')
+		switch seg {
+		case ".":
+			sb.WriteString("%2E")
+		case "..":
+			sb.WriteString("%2E%2E")
+		default:
+			sb.WriteString(percentEncode(seg, segmentEncodeSet))
+		}
+	}
+	b.u.Path = sb.String()
+	return b
+}
+
+// SetQuery replaces every parameter named key with a single one holding value, in place of the first one
+func (b *Builder) SetQuery(key, value string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	param := url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	b.u.Query = b.filterQuery(key, param)
+	return b
+}
+
+// AddQuery appends a parameter, keeping any others with the same key
+func (b *Builder) AddQuery(key, value string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	param := url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	if b.u.Query == "" {
+		b.u.Query = param
+	} else {
+		b.u.Query += "&" + param
+	}
+	return b
+}
+
+// DelQuery removes every parameter named key
+func (b *Builder) DelQuery(key string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.u.Query = b.filterQuery(key, "")
+	return b
+}
+
+// filterQuery drops the parameters named key, putting replacement in place of the first one when it isn't empty
+func (b *Builder) filterQuery(key, replacement string) string {
+	var params []string
+	for _, param := range strings.Split(b.u.Query, "&") {
+		k := param
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			k = param[:i]
+		}
+		if dk, err := url.QueryUnescape(k); err == nil && dk == key {
+			if replacement != "" {
+				params = append(params, replacement)
+				replacement = ""
+			}
+			continue
+		}
+		if param != "" {
+			params = append(params, param)
+		}
+	}
+	if replacement != "" {
+		params = append(params, replacement)
+	}
+	return strings.Join(params, "&")
+}
+
+// WithFragment sets the fragment, an empty one removes it
+func (b *Builder) WithFragment(fragment string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.u.Fragment = percentEncode(fragment, fragmentEncodeSet+"#%")
+	return b
+}
+
+// URL returns the built URL, or the first error met while building it
+func (b *Builder) URL() (*URL, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	u := b.u
+	if u.Host != "" && u.Path != "" && u.Path[0] != '/' {
+		u.Path = "/" + u.Path
+	}
+	if u.Host == "" && (u.User != "" || u.Port != "") {
+		return nil, fmt.Errorf("fasturl: userinfo or port without a host")
+	}
+	if _, err := ParseURL(u.String()); err != nil {
+		return nil, fmt.Errorf("fasturl: built URL %q doesn't parse", u.String())
+	}
+	return &u, nil
+}
+
+// String returns the built URL as a string, or the first error met while building it
+func (b *Builder) String() (string, error) {
+	u, err := b.URL()
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,143 @@
+package fasturl
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// BulkResult is the outcome of parsing a single input line
+type BulkResult struct {
+	Line  int // 1-based line number in the input
+	Input string
+	URL   *URL
+	Err   error
+}
+
+// BulkParser parses newline-delimited URLs from an `io.Reader` using a pool of workers
+type BulkParser struct {
+	// Workers is the number of parsing goroutines, defaults to GOMAXPROCS
+	Workers int
+	// Unordered emits results as soon as they are parsed instead of in input order
+	Unordered bool
+	// InFlight bounds the number of lines read but not yet handed to the callback, defaults to 64 per worker
+	InFlight int
+	// MaxLineSize is the longest accepted line in bytes, defaults to 64KiB
+	MaxLineSize int
+}
+
+type bulkJob struct {
+	line  int
+	input string
+}
+
+// Run reads every line of r, parses it with `ParseURL` and calls fn with the result.
+// fn is always called from the goroutine that called Run, so it needs no locking.
+// Reading stops when ctx is cancelled, fn returns an error or r fails, and that error is returned.
+func (p *BulkParser) Run(ctx context.Context, r io.Reader, fn func(BulkResult) error) error {
+	workers := p.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	inFlight := p.InFlight
+	if inFlight <= 0 {
+		inFlight = 64 * workers
+	}
+	maxLine := p.MaxLineSize
+	if maxLine <= 0 {
+		maxLine = bufio.MaxScanTokenSize
+	}
+	bufSize := 4096
+	if bufSize > maxLine {
+		bufSize = maxLine
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// every line holds a token from the moment it is read until fn has seen it,
+	// which bounds both memory and the reorder buffer
+	tokens := make(chan struct{}, inFlight)
+	jobs := make(chan bulkJob, workers)
+	results := make(chan BulkResult, workers)
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, bufSize), maxLine)
+		for n := 1; sc.Scan(); n++ {
+			select {
+			case tokens <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- bulkJob{line: n, input: strings.TrimSuffix(sc.Text(), "\r")}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr = sc.Err()
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				u, err := ParseURL(job.input)
+				select {
+				case results <- BulkResult{Line: job.line, Input: job.input, URL: u, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var err error
+	emit := func(res BulkResult) {
+		if err == nil && ctx.Err() == nil {
+			err = fn(res)
+		}
+		<-tokens
+		if err != nil {
+			cancel()
+		}
+	}
+
+	next, pending := 1, map[int]BulkResult{}
+	for res := range results {
+		if p.Unordered {
+			emit(res)
+			continue
+		}
+		pending[res.Line] = res
+		for {
+			res, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			emit(res)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return readErr
+}
@@ -0,0 +1,128 @@
+package fasturl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bulkInput(n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if i%10 == 9 {
+			sb.WriteString("not a url\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "https://www.google.com/dir/%d/search.html?arg=%d#hash\r\n", i, i)
+	}
+	return sb.String()
+}
+
+func TestBulkParser(t *testing.T) {
+	t.Run("Ordered", func(t *testing.T) {
+		p := &BulkParser{Workers: 4, InFlight: 8}
+
+		var lines []int
+		err := p.Run(context.Background(), strings.NewReader(bulkInput(1000)), func(res BulkResult) error {
+			lines = append(lines, res.Line)
+			if res.Line%10 == 0 {
+				assert.Error(t, res.Err)
+				assert.Nil(t, res.URL)
+				return nil
+			}
+			require.NoError(t, res.Err)
+			assert.Equal(t, fmt.Sprintf("/dir/%d/search.html", res.Line-1), res.URL.Path)
+			assert.Equal(t, fmt.Sprintf("arg=%d", res.Line-1), res.URL.Query)
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Len(t, lines, 1000)
+		assert.True(t, sort.IntsAreSorted(lines))
+	})
+
+	t.Run("Unordered", func(t *testing.T) {
+		p := &BulkParser{Workers: 4, Unordered: true}
+
+		var lines []int
+		err := p.Run(context.Background(), strings.NewReader(bulkInput(1000)), func(res BulkResult) error {
+			lines = append(lines, res.Line)
+			return nil
+		})
+
+		require.NoError(t, err)
+		sort.Ints(lines)
+		for i, n := range lines {
+			require.Equal(t, i+1, n)
+		}
+	})
+
+	t.Run("Callback error", func(t *testing.T) {
+		stop := errors.New("stop")
+		p := &BulkParser{Workers: 2}
+
+		calls := 0
+		err := p.Run(context.Background(), strings.NewReader(bulkInput(1000)), func(res BulkResult) error {
+			calls++
+			if res.Line == 5 {
+				return stop
+			}
+			return nil
+		})
+
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 5, calls)
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := &BulkParser{Workers: 2}
+
+		calls := 0
+		err := p.Run(ctx, strings.NewReader(bulkInput(1000)), func(res BulkResult) error {
+			calls++
+			cancel()
+			return nil
+		})
+
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("Line too long", func(t *testing.T) {
+		p := &BulkParser{MaxLineSize: 16}
+
+		err := p.Run(context.Background(), strings.NewReader(bulkInput(1)), func(BulkResult) error { return nil })
+
+		assert.Error(t, err)
+	})
+}
+
+func BenchmarkBulkParser(b *testing.B) {
+	input := bulkInput(10000)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			p := &BulkParser{Workers: workers}
+			b.ReportAllocs()
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i++ {
+				err := p.Run(context.Background(), strings.NewReader(input), func(res BulkResult) error {
+					if res.Err == nil {
+						hits++
+					}
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
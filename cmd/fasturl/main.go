@@ -0,0 +1,185 @@
+// Command fasturl parses URLs read line by line from stdin, for use in shell pipelines.
+//
+// Usage:
+//
+//	fasturl parse [-format json|tsv]   print the components of every URL
+//	fasturl get <field>                print one component: protocol, user, host, port, path, query, fragment or query.<key>
+//	fasturl normalize                  print every URL in RFC 3986 normal form
+//	fasturl resolve <base>             resolve every reference against base
+//	fasturl validate                   report invalid URLs and exit non-zero if there were any
+//
+// Lines that fail to parse are reported on stderr and make fasturl exit with status 1.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ImVexed/fasturl"
+)
+
+const usage = `usage: fasturl <command> [arguments]
+
+commands:
+  parse [-format json|tsv]  print the components of every URL
+  get <field>               print protocol, user, host, port, path, query, fragment or query.<key>
+  normalize                 print every URL in RFC 3986 normal form
+  resolve <base>            resolve every reference against base
+  validate                  report invalid URLs and exit non-zero if there were any
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// component is the output record of `fasturl parse -format json`
+type component struct {
+	Input    string `json:"input"`
+	Protocol string `json:"protocol"`
+	User     string `json:"user"`
+	Host     string `json:"host"`
+	Port     string `json:"port"`
+	Path     string `json:"path"`
+	Query    string `json:"query"`
+	Fragment string `json:"fragment"`
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("fasturl "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	workers := fs.Int("workers", 0, "number of parsing goroutines, defaults to GOMAXPROCS")
+	format := fs.String("format", "json", "output format of parse: json or tsv")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+
+	failed := false
+	var write func(res fasturl.BulkResult) error
+	switch args[0] {
+	case "parse":
+		switch *format {
+		case "json":
+			enc := json.NewEncoder(out)
+			enc.SetEscapeHTML(false)
+			write = func(res fasturl.BulkResult) error {
+				u := res.URL
+				return enc.Encode(component{res.Input, u.Protocol, u.User, u.Host, u.Port, u.Path, u.Query, u.Fragment})
+			}
+		case "tsv":
+			fmt.Fprintln(out, "protocol\tuser\thost\tport\tpath\tquery\tfragment")
+			write = func(res fasturl.BulkResult) error {
+				u := res.URL
+				_, err := fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", u.Protocol, u.User, u.Host, u.Port, u.Path, u.Query, u.Fragment)
+				return err
+			}
+		default:
+			fmt.Fprintf(stderr, "fasturl parse: unknown format %q\n", *format)
+			return 2
+		}
+	case "get":
+		if fs.NArg() != 1 {
+			fmt.Fprint(stderr, usage)
+			return 2
+		}
+		get, err := getter(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "fasturl get: %v\n", err)
+			return 2
+		}
+		write = func(res fasturl.BulkResult) error {
+			_, err := fmt.Fprintln(out, get(res.URL))
+			return err
+		}
+	case "normalize":
+		write = func(res fasturl.BulkResult) error {
+			_, err := fmt.Fprintln(out, fasturl.Normalize(res.URL))
+			return err
+		}
+	case "resolve":
+		if fs.NArg() != 1 {
+			fmt.Fprint(stderr, usage)
+			return 2
+		}
+		base, err := fasturl.ParseURL(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "fasturl resolve: base %q: %v\n", fs.Arg(0), err)
+			return 2
+		}
+		write = func(res fasturl.BulkResult) error {
+			u, err := base.Resolve(res.Input)
+			if err != nil {
+				failed = true
+				fmt.Fprintf(stderr, "line %d: %q: %v\n", res.Line, res.Input, err)
+				return nil
+			}
+			_, err = fmt.Fprintln(out, u)
+			return err
+		}
+	case "validate":
+		write = func(fasturl.BulkResult) error { return nil }
+	default:
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	p := &fasturl.BulkParser{Workers: *workers}
+	err := p.Run(context.Background(), stdin, func(res fasturl.BulkResult) error {
+		// resolve reports the errors of Resolve, which parses references the bulk parser may reject
+		if res.Err != nil && args[0] != "resolve" {
+			failed = true
+			fmt.Fprintf(stderr, "line %d: %q: %v\n", res.Line, res.Input, res.Err)
+			return nil
+		}
+		return write(res)
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "fasturl %s: %v\n", args[0], err)
+		return 1
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// getter returns a function extracting the named field from a URL
+func getter(field string) (func(*fasturl.URL) string, error) {
+	switch field {
+	case "protocol", "scheme":
+		return func(u *fasturl.URL) string { return u.Protocol }, nil
+	case "user":
+		return func(u *fasturl.URL) string { return u.User }, nil
+	case "host":
+		return func(u *fasturl.URL) string { return u.Host }, nil
+	case "port":
+		return func(u *fasturl.URL) string { return u.Port }, nil
+	case "path":
+		return func(u *fasturl.URL) string { return u.Path }, nil
+	case "query":
+		return func(u *fasturl.URL) string { return u.Query }, nil
+	case "fragment":
+		return func(u *fasturl.URL) string { return u.Fragment }, nil
+	}
+	if key := strings.TrimPrefix(field, "query."); key != field && key != "" {
+		return func(u *fasturl.URL) string {
+			v, _ := u.QueryValue(key)
+			return v
+		}, nil
+	}
+	return nil, errors.New("unknown field " + field)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func runCommand(input string, args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(input), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestRun(t *testing.T) {
+	input := "https://www.google.com/dir/1/2/search.html?arg=0-a&arg1=1-b#hash\nHTTP://Example.COM:80/a/./b\n"
+
+	t.Run("Parse JSON", func(t *testing.T) {
+		stdout, _, code := runCommand(input, "parse")
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, `{"input":"https://www.google.com/dir/1/2/search.html?arg=0-a&arg1=1-b#hash","protocol":"https","user":"","host":"www.google.com","port":"","path":"/dir/1/2/search.html","query":"arg=0-a&arg1=1-b","fragment":"hash"}
+{"input":"HTTP://Example.COM:80/a/./b","protocol":"HTTP","user":"","host":"Example.COM","port":"80","path":"/a/./b","query":"","fragment":""}
+`, stdout)
+	})
+
+	t.Run("Parse TSV", func(t *testing.T) {
+		stdout, _, code := runCommand(input, "parse", "-format", "tsv")
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "protocol\tuser\thost\tport\tpath\tquery\tfragment\n"+
+			"https\t\twww.google.com\t\t/dir/1/2/search.html\targ=0-a&arg1=1-b\thash\n"+
+			"HTTP\t\tExample.COM\t80\t/a/./b\t\t\n", stdout)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		stdout, _, code := runCommand(input, "get", "host")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "www.google.com\nExample.COM\n", stdout)
+
+		stdout, _, code = runCommand(input, "get", "query.arg1")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "1-b\n\n", stdout)
+
+		_, _, code = runCommand(input, "get", "nope")
+		assert.Equal(t, 2, code)
+	})
+
+	t.Run("Normalize", func(t *testing.T) {
+		stdout, _, code := runCommand(input, "normalize")
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "https://www.google.com/dir/1/2/search.html?arg=0-a&arg1=1-b#hash\nhttp://example.com/a/b\n", stdout)
+	})
+
+	t.Run("Resolve", func(t *testing.T) {
+		stdout, _, code := runCommand("g\n../h?x\n//other/\n", "resolve", "http://a/b/c/d;p?q")
+
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "http://a/b/c/g\nhttp://a/b/h?x\nhttp://other/\n", stdout)
+
+		stdout, stderr, code := runCommand("g?y/../x\nhttp://[::1\ng\n", "resolve", "http://a/b/c/d;p?q")
+
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "http://a/b/c/g?y/../x\nhttp://a/b/c/g\n", stdout)
+		assert.Contains(t, stderr, `line 2: "http://[::1"`)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		stdout, stderr, code := runCommand(input+"I'm not a url\n", "validate")
+
+		assert.Equal(t, 1, code)
+		assert.Empty(t, stdout)
+		assert.Contains(t, stderr, `line 3: "I'm not a url"`)
+
+		_, _, code = runCommand(input, "validate")
+		assert.Equal(t, 0, code)
+	})
+
+	t.Run("Usage", func(t *testing.T) {
+		_, stderr, code := runCommand("", "frobnicate")
+
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "usage: fasturl")
+	})
+}
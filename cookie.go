@@ -0,0 +1,61 @@
+package fasturl
+
+import (
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DomainMatch reports whether host domain-matches cookieDomain as defined by RFC 6265 section 5.1.3.
+// A leading dot on cookieDomain is ignored. IP address hosts only match themselves, and a cookieDomain that is a public suffix
+// such as "co.uk" only matches a host equal to it, so a cookie can't be set for a whole registry.
+func DomainMatch(host, cookieDomain string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	domain := strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(cookieDomain, "."), "."))
+	if host == "" || domain == "" {
+		return false
+	}
+	if host == domain {
+		return true
+	}
+	if _, ip := ClassifyHost(host); ip != nil {
+		return false
+	}
+	if !strings.HasSuffix(host, domain) || host[len(host)-len(domain)-1] != '.' {
+		return false
+	}
+	return !IsPublicSuffix(domain)
+}
+
+// IsPublicSuffix reports whether domain is a public suffix, a domain under which anyone can register names.
+// Top level domains missing from the list, such as "internal", count as public suffixes.
+func IsPublicSuffix(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	suffix, _ := publicsuffix.PublicSuffix(domain)
+	return suffix == domain
+}
+
+// PathMatch reports whether requestPath path-matches cookiePath as defined by RFC 6265 section 5.1.4
+func PathMatch(requestPath, cookiePath string) bool {
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return len(requestPath) == len(cookiePath) ||
+		strings.HasSuffix(cookiePath, "/") ||
+		requestPath[len(cookiePath)] == '/'
+}
+
+// DefaultCookiePath returns the path a cookie set by a response to u defaults to, per RFC 6265 section 5.1.4
+func DefaultCookiePath(u *URL) string {
+	if u.Path == "" || u.Path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndexByte(u.Path, '/')
+	if i == 0 {
+		return "/"
+	}
+	return u.Path[:i]
+}
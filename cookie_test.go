@@ -0,0 +1,82 @@
+package fasturl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainMatch(t *testing.T) {
+	for _, tc := range []struct {
+		host, domain string
+		match        bool
+	}{
+		{"example.com", "example.com", true},
+		{"www.example.com", "example.com", true},
+		{"www.example.com", ".example.com", true},
+		{"a.b.example.com", "example.com", true},
+		{"WWW.Example.COM", "example.com", true},
+		{"example.com.", "example.com", true},
+		{"example.com", "www.example.com", false},
+		{"notexample.com", "example.com", false},
+		{"example.com.evil", "example.com", false},
+		{"www.example.co.uk", "example.co.uk", true},
+		{"www.example.co.uk", "co.uk", false},
+		{"www.example.com", "com", false},
+		{"alice.github.io", "github.io", false},
+		{"co.uk", "co.uk", true},
+		{"192.168.0.1", "192.168.0.1", true},
+		{"192.168.0.1", "168.0.1", false},
+		{"10.0.0.1", "0.1", false},
+		{"[::1]", "[::1]", true},
+		{"", "example.com", false},
+		{"example.com", "", false},
+		{"example.com", ".", false},
+	} {
+		assert.Equal(t, tc.match, DomainMatch(tc.host, tc.domain), "%s %s", tc.host, tc.domain)
+	}
+}
+
+func TestIsPublicSuffix(t *testing.T) {
+	assert.True(t, IsPublicSuffix("com"))
+	assert.True(t, IsPublicSuffix("co.uk"))
+	assert.True(t, IsPublicSuffix("github.io"))
+	assert.True(t, IsPublicSuffix("internal"))
+	assert.True(t, IsPublicSuffix("CO.UK"))
+	assert.True(t, IsPublicSuffix("co.uk."))
+	assert.False(t, IsPublicSuffix("example.com"))
+	assert.False(t, IsPublicSuffix("example.co.uk"))
+}
+
+func TestPathMatch(t *testing.T) {
+	for _, tc := range []struct {
+		path, cookiePath string
+		match            bool
+	}{
+		{"/", "/", true},
+		{"/docs", "/docs", true},
+		{"/docs/", "/docs", true},
+		{"/docs/web", "/docs", true},
+		{"/docs/web", "/docs/", true},
+		{"/docsets", "/docs", false},
+		{"/doc", "/docs", false},
+		{"/Docs", "/docs", false},
+		{"", "/", true},
+		{"/anything", "/", true},
+	} {
+		assert.Equal(t, tc.match, PathMatch(tc.path, tc.cookiePath), "%s %s", tc.path, tc.cookiePath)
+	}
+}
+
+func TestDefaultCookiePath(t *testing.T) {
+	for in, want := range map[string]string{
+		"http://example.com":                "/",
+		"http://example.com/":               "/",
+		"http://example.com/login":          "/",
+		"http://example.com/docs/":          "/docs",
+		"http://example.com/docs/web/index": "/docs/web",
+		"http://example.com/docs/web?q=1":   "/docs",
+	} {
+		assert.Equal(t, want, DefaultCookiePath(mustParse(t, in)), in)
+	}
+}
@@ -0,0 +1,348 @@
+package fasturl
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// DataURL is a "data:" URL decoded by the data: URL processor of the WHATWG Fetch standard
+type DataURL struct {
+	// MediaType is the lower cased essence of the MIME type, as in "text/plain"
+	MediaType string
+	// Params are the parameters of the MIME type, such as "charset", keyed by their lower cased name
+	Params map[string]string
+	// Base64 is set when the body is base64 encoded
+	Base64 bool
+	// body is the body as written, still percent-encoded and base64 encoded
+	body string
+}
+
+var errInvalidDataURL = errors.New("fasturl: invalid data URL")
+
+func dataURLError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{errInvalidDataURL}, args...)...)
+}
+
+// ParseDataURL parses a data URL such as "data:text/plain;base64,SGVsbG8=". A MIME type that doesn't parse falls back to
+// "text/plain;charset=US-ASCII" as browsers do, while a missing comma or an invalid base64 body fail.
+// The body isn't decoded until it is read through `DataURL.Body` or `DataURL.Bytes`.
+func ParseDataURL(s string) (*DataURL, error) {
+	// the URL parser strips leading and trailing C0 controls and spaces and removes tabs and newlines
+	s = strings.TrimFunc(s, func(r rune) bool { return r <= ' ' })
+	if strings.ContainsAny(s, "\t\n\r") {
+		s = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(s)
+	}
+	if len(s) < 5 || !strings.EqualFold(s[:5], "data:") {
+		return nil, dataURLError("scheme isn't data")
+	}
+	s = s[5:]
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		s = s[:i]
+	}
+	comma := strings.IndexByte(s, ',')
+	if comma < 0 {
+		return nil, dataURLError("missing ','")
+	}
+
+	d := &DataURL{body: s[comma+1:]}
+	mimeType := strings.Trim(s[:comma], "\t\n\f\r ")
+	if n := len(mimeType); n >= 6 && strings.EqualFold(mimeType[n-6:], "base64") {
+		if rest := strings.TrimRight(mimeType[:n-6], " "); strings.HasSuffix(rest, ";") {
+			d.Base64 = true
+			mimeType = rest[:len(rest)-1]
+		}
+	}
+	if d.Base64 {
+		if err := checkForgivingBase64(d.body); err != nil {
+			return nil, err
+		}
+	}
+
+	if strings.HasPrefix(mimeType, ";") {
+		mimeType = "text/plain" + mimeType
+	}
+	var ok bool
+	if d.MediaType, d.Params, ok = parseMIMEType(mimeType); !ok {
+		d.MediaType, d.Params = "text/plain", map[string]string{"charset": "US-ASCII"}
+	}
+	return d, nil
+}
+
+// EncodeDataURL builds a data URL holding data, base64 encoded unless percent-encoding it is shorter.
+// An empty mediaType leaves the MIME type out, which stands for "text/plain;charset=US-ASCII".
+func EncodeDataURL(mediaType string, data []byte) (string, error) {
+	d := &DataURL{}
+	if mediaType != "" {
+		var ok bool
+		if d.MediaType, d.Params, ok = parseMIMEType(mediaType); !ok {
+			return "", dataURLError("invalid media type %q", mediaType)
+		}
+		if strings.ContainsAny(d.ContentType(), ",#") {
+			return "", dataURLError("media type %q can't be written in a data URL", mediaType)
+		}
+	}
+
+	escaped := 0
+	for _, c := range data {
+		if !isDataURLChar(c) {
+			escaped++
+		}
+	}
+	if len(";base64")+base64.StdEncoding.EncodedLen(len(data)) < len(data)+2*escaped {
+		d.Base64 = true
+		d.body = base64.StdEncoding.EncodeToString(data)
+		return d.String(), nil
+	}
+
+	const upperHex = "0123456789ABCDEF"
+	var sb strings.Builder
+	sb.Grow(len(data) + 2*escaped)
+	for _, c := range data {
+		if isDataURLChar(c) {
+			sb.WriteByte(c)
+		} else {
+			sb.WriteByte('%')
+			sb.WriteByte(upperHex[c>>4])
+			sb.WriteByte(upperHex[c&15])
+		}
+	}
+	d.body = sb.String()
+	return d.String(), nil
+}
+
+// ContentType returns the MIME type with its parameters, suitable for a Content-Type header
+func (d *DataURL) ContentType() string {
+	if d.MediaType == "" {
+		return ""
+	}
+	names := make([]string, 0, len(d.Params))
+	for name := range d.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(d.MediaType)
+	for _, name := range names {
+		sb.WriteByte(';')
+		sb.WriteString(name)
+		sb.WriteByte('=')
+		writeLinkParamValue(&sb, d.Params[name])
+	}
+	return sb.String()
+}
+
+// String serializes d with its MIME type normalized and its body as it was written
+func (d *DataURL) String() string {
+	var sb strings.Builder
+	sb.WriteString("data:")
+	sb.WriteString(d.ContentType())
+	if d.Base64 {
+		sb.WriteString(";base64")
+	}
+	sb.WriteByte(',')
+	sb.WriteString(d.body)
+	return sb.String()
+}
+
+// Body returns a reader decoding the body as it is read, so large payloads are never held decoded in memory
+func (d *DataURL) Body() io.Reader {
+	r := &dataURLReader{dec: percentDecoder{d.body}, base64: d.Base64}
+	if d.Base64 {
+		return base64.NewDecoder(base64.RawStdEncoding, r)
+	}
+	return r
+}
+
+// Bytes returns the decoded body
+func (d *DataURL) Bytes() []byte {
+	// the body was validated by ParseDataURL so reading it can't fail
+	data, _ := ioutil.ReadAll(d.Body())
+	return data
+}
+
+// percentDecoder yields the bytes s percent-decodes to, a '%' not followed by two hex digits stands for itself
+type percentDecoder struct {
+	s string
+}
+
+func (p *percentDecoder) next() (byte, bool) {
+	if p.s == "" {
+		return 0, false
+	}
+	c := p.s[0]
+	if c == '%' && len(p.s) >= 3 && isHex(p.s[1]) && isHex(p.s[2]) {
+		c = unhex(p.s[1])<<4 | unhex(p.s[2])
+		p.s = p.s[3:]
+	} else {
+		p.s = p.s[1:]
+	}
+	return c, true
+}
+
+// dataURLReader reads a percent-decoded body, without the whitespace and padding of a base64 one
+type dataURLReader struct {
+	dec    percentDecoder
+	base64 bool
+}
+
+func (r *dataURLReader) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		c, ok := r.dec.next()
+		if !ok {
+			if n == 0 {
+				return 0, io.EOF
+			}
+			break
+		}
+		if r.base64 && (isASCIIWhitespace(c) || c == '=') {
+			continue
+		}
+		b[n] = c
+		n++
+	}
+	return n, nil
+}
+
+// checkForgivingBase64 checks that the percent-decoded body is accepted by the forgiving-base64 decode of the
+// WHATWG Infra standard: ASCII whitespace is ignored and padding is optional but must be complete when present
+func checkForgivingBase64(body string) error {
+	dec := percentDecoder{body}
+	n, pad := 0, 0
+	for c, ok := dec.next(); ok; c, ok = dec.next() {
+		switch {
+		case isASCIIWhitespace(c):
+		case c == '=':
+			n++
+			pad++
+		case pad > 0 || !isBase64Char(c):
+			return dataURLError("invalid base64 body")
+		default:
+			n++
+		}
+	}
+	if pad > 2 || pad > 0 && n%4 != 0 || (n-pad)%4 == 1 {
+		return dataURLError("invalid base64 body")
+	}
+	return nil
+}
+
+// parseMIMEType parses a MIME type following the WHATWG MIME Sniffing standard, invalid parameters are skipped
+func parseMIMEType(s string) (essence string, params map[string]string, ok bool) {
+	const httpWhitespace = "\t\n\r "
+	s = strings.Trim(s, httpWhitespace)
+	slash := strings.IndexByte(s, '/')
+	if slash < 0 {
+		return "", nil, false
+	}
+	typ, s := s[:slash], s[slash+1:]
+	end := strings.IndexByte(s, ';')
+	if end < 0 {
+		end = len(s)
+	}
+	subtype := strings.TrimRight(s[:end], httpWhitespace)
+	if !isToken(typ) || !isToken(subtype) {
+		return "", nil, false
+	}
+
+	params = map[string]string{}
+	for s = s[end:]; s != ""; {
+		// s starts with the ';' ending the previous part
+		s = strings.TrimLeft(s[1:], httpWhitespace)
+		end := strings.IndexAny(s, ";=")
+		if end < 0 {
+			end = len(s)
+		}
+		name := strings.ToLower(s[:end])
+		if s = s[end:]; s == "" {
+			break
+		}
+		if s[0] == ';' {
+			continue
+		}
+
+		var value string
+		if s = s[1:]; s != "" && s[0] == '"' {
+			value, s = collectQuotedString(s)
+			if end := strings.IndexByte(s, ';'); end >= 0 {
+				s = s[end:]
+			} else {
+				s = ""
+			}
+		} else {
+			end := strings.IndexByte(s, ';')
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = strings.TrimRight(s[:end], httpWhitespace), s[end:]
+			if value == "" {
+				continue
+			}
+		}
+		if _, seen := params[name]; !seen && isToken(name) && isQuotedStringValue(value) {
+			params[name] = value
+		}
+	}
+	return strings.ToLower(typ) + "/" + strings.ToLower(subtype), params, true
+}
+
+// collectQuotedString reads the quoted string s starts with, unescaping it, and returns the rest of s.
+// An unterminated string runs to the end of s.
+func collectQuotedString(s string) (value, rest string) {
+	var sb strings.Builder
+	i := 1
+	for i < len(s) {
+		c := s[i]
+		i++
+		if c == '"' {
+			break
+		}
+		if c == '\\' {
+			if i == len(s) {
+				sb.WriteByte('\\')
+				break
+			}
+			c = s[i]
+			i++
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), s[i:]
+}
+
+func isToken(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isTokenChar(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isQuotedStringValue(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c != '\t' && (c < 0x20 || c == 0x7f) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIIWhitespace(c byte) bool {
+	return c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isBase64Char(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '+' || c == '/'
+}
+
+// isDataURLChar reports whether c can be written as is in the body of a data URL
+func isDataURLChar(c byte) bool {
+	return isUnreserved(c) || strings.IndexByte("!$&'()*+,;=:@/", c) >= 0
+}
@@ -0,0 +1,170 @@
+package fasturl
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDataURL(t *testing.T) {
+	// from the data-urls.json cases of the web-platform-tests, an empty contentType marks a failure
+	for _, tc := range []struct {
+		in, contentType, body string
+	}{
+		{"data:text/plain;base64,SGVsbG8=", "text/plain", "Hello"},
+		{"data:,X", "text/plain;charset=US-ASCII", "X"},
+		{"DATA:,X", "text/plain;charset=US-ASCII", "X"},
+		{"  data:,X\n", "text/plain;charset=US-ASCII", "X"},
+		{"data:", "", ""},
+		{"data:text/html", "", ""},
+		{"data:text/html    ;charset=x   ", "", ""},
+		{"data:,", "text/plain;charset=US-ASCII", ""},
+		{"data:,X#X", "text/plain;charset=US-ASCII", "X"},
+		{"data:,%FF", "text/plain;charset=US-ASCII", "\xff"},
+		{"data:,%F", "text/plain;charset=US-ASCII", "%F"},
+		{"data:text/plain,X", "text/plain", "X"},
+		{"data:text/plain ,X", "text/plain", "X"},
+		{"data:text/plain%20,X", "text/plain%20", "X"},
+		{"data:text/plain%0C,X", "text/plain%0c", "X"},
+		{"data:text/plain;,X", "text/plain", "X"},
+		{"data:;x=x;charset=x,X", "text/plain;charset=x;x=x", "X"},
+		{"data:;x=x,X", "text/plain;x=x", "X"},
+		{"data:text/plain;charset=windows-1252,%C2%B1", "text/plain;charset=windows-1252", "\xc2\xb1"},
+		{"data:text/plain;Charset=UTF-8,%C2%B1", "text/plain;charset=UTF-8", "\xc2\xb1"},
+		{"data:IMAGE/gif;hi=x,%C2%B1", "image/gif;hi=x", "\xc2\xb1"},
+		{"data:IMAGE/gif;CHARSET=x,%C2%B1", "image/gif;charset=x", "\xc2\xb1"},
+		{"data: ,%FF", "text/plain;charset=US-ASCII", "\xff"},
+		{"data:%20,%FF", "text/plain;charset=US-ASCII", "\xff"},
+		{"data:%00,%FF", "text/plain;charset=US-ASCII", "\xff"},
+		{"data:text/html  ,X", "text/html", "X"},
+		{"data:text / html,X", "text/plain;charset=US-ASCII", "X"},
+		{"data:†,X", "text/plain;charset=US-ASCII", "X"},
+		{"data:X,X", "text/plain;charset=US-ASCII", "X"},
+		{"data:image/png,X X", "image/png", "X X"},
+		{`data:text/plain;a=",",X`, `text/plain;a=""`, `",X`},
+		{"data:text/plain;a=%2C,X", "text/plain;a=%2C", "X"},
+		{"data:;base64;base64,WA", "text/plain", "X"},
+		{"data:x/x;base64;base64,WA", "x/x", "X"},
+		{"data:x/x;base64;charset=x,WA", "x/x;charset=x", "WA"},
+		{"data:x/x;base64;charset=x;base64,WA", "x/x;charset=x", "X"},
+		{"data:x/x;base64;base64x,WA", "x/x", "WA"},
+		{"data:;base64,W%20A", "text/plain;charset=US-ASCII", "X"},
+		{"data:;base64,W%0CA", "text/plain;charset=US-ASCII", "X"},
+		{"data:x;base64x,WA", "text/plain;charset=US-ASCII", "WA"},
+		{"data:x;base64;x,WA", "text/plain;charset=US-ASCII", "WA"},
+		{"data:x;base64=x,WA", "text/plain;charset=US-ASCII", "WA"},
+		{"data:; base64,WA", "text/plain;charset=US-ASCII", "X"},
+		{"data:;  base64,WA", "text/plain;charset=US-ASCII", "X"},
+		{"data:  ;charset=x   ;  base64,WA", "text/plain;charset=x", "X"},
+		{"data:;base64;,WA", "text/plain", "WA"},
+		{"data:;base64 ,WA", "text/plain;charset=US-ASCII", "X"},
+		{"data:;base 64,WA", "text/plain", "WA"},
+		{"data:;BASe64,WA", "text/plain;charset=US-ASCII", "X"},
+		{"data:;%62ase64,WA", "text/plain", "WA"},
+		{"data:%3Bbase64,WA", "text/plain;charset=US-ASCII", "WA"},
+		{"data:;charset=x,X", "text/plain;charset=x", "X"},
+		{"data:; charset=x,X", "text/plain;charset=x", "X"},
+		{"data:;charset =x,X", "text/plain", "X"},
+		{"data:;charset= x,X", `text/plain;charset=" x"`, "X"},
+		{"data:;charset=,X", "text/plain", "X"},
+		{"data:;charset,X", "text/plain", "X"},
+		{`data:;charset="x",X`, "text/plain;charset=x", "X"},
+		{`data:;CHARSET="X",X`, "text/plain;charset=X", "X"},
+		{`data:;charset="\x\\",X`, `text/plain;charset="x\\"`, "X"},
+		{"http://example.com/", "", ""},
+	} {
+		d, err := ParseDataURL(tc.in)
+		if tc.contentType == "" {
+			assert.True(t, errors.Is(err, errInvalidDataURL), tc.in)
+			continue
+		}
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.contentType, d.ContentType(), tc.in)
+		assert.Equal(t, tc.body, string(d.Bytes()), tc.in)
+	}
+
+	t.Run("Forgiving base64", func(t *testing.T) {
+		// from the base64.json cases of the web-platform-tests, an empty want marks a failure
+		for in, want := range map[string]string{
+			"":       "",
+			"abcd":   "i\xb7\x1d",
+			" abcd":  "i\xb7\x1d",
+			"abcd ":  "i\xb7\x1d",
+			"ab==":   "i",
+			"abc=":   "i\xb7",
+			"ab":     "i",
+			"abc":    "i\xb7",
+			"ab\tcd": "i\xb7\x1d",
+			"ab%20c": "i\xb7",
+			"ab=":    "",
+			"abcde":  "",
+			"a":      "",
+			"ab===":  "",
+			"abcd=":  "",
+			"ab=c":   "",
+			"====":   "",
+			"ab*c":   "",
+			"=":      "",
+		} {
+			d, err := ParseDataURL("data:;base64," + in)
+			if want == "" && in != "" {
+				assert.True(t, errors.Is(err, errInvalidDataURL), in)
+				continue
+			}
+			require.NoError(t, err, in)
+			assert.Equal(t, want, string(d.Bytes()), in)
+		}
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		payload := make([]byte, 100000)
+		for i := range payload {
+			payload[i] = byte(i * 7)
+		}
+		s, err := EncodeDataURL("application/octet-stream", payload)
+		require.NoError(t, err)
+		d, err := ParseDataURL(s)
+		require.NoError(t, err)
+		assert.True(t, d.Base64)
+
+		var buf bytes.Buffer
+		_, err = io.CopyBuffer(&buf, struct{ io.Reader }{d.Body()}, make([]byte, 7))
+		require.NoError(t, err)
+		assert.Equal(t, payload, buf.Bytes())
+	})
+}
+
+func TestEncodeDataURL(t *testing.T) {
+	for _, tc := range []struct {
+		mediaType string
+		data      string
+		want      string
+	}{
+		{"text/plain", "Hello", "data:text/plain,Hello"},
+		{"Text/HTML; Charset=UTF-8", "<p>a b</p>", "data:text/html;charset=UTF-8,%3Cp%3Ea%20b%3C/p%3E"},
+		{"", "a,b#c", "data:,a,b%23c"},
+		{"image/png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", "data:image/png;base64,iVBORw0KGgoAAAANSUhEUg=="},
+		{"image/png", "\x89PNG\r\n\x1a\n", "data:image/png,%89PNG%0D%0A%1A%0A"},
+		{"text/plain", "", "data:text/plain,"},
+	} {
+		s, err := EncodeDataURL(tc.mediaType, []byte(tc.data))
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, s)
+
+		d, err := ParseDataURL(s)
+		require.NoError(t, err)
+		assert.Equal(t, tc.data, string(d.Bytes()))
+		if tc.mediaType != "" {
+			assert.Equal(t, s, d.String())
+		}
+	}
+
+	for _, mediaType := range []string{"text", "text/plain;a=\"b,c\"", "text /plain"} {
+		_, err := EncodeDataURL(mediaType, []byte("x"))
+		assert.True(t, errors.Is(err, errInvalidDataURL), mediaType)
+	}
+}
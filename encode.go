@@ -0,0 +1,81 @@
+package fasturl
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// specialSchemes are the special schemes of the WHATWG URL standard
+var specialSchemes = map[string]bool{"ftp": true, "file": true, "http": true, "https": true, "ws": true, "wss": true}
+
+func isSpecialScheme(scheme string) bool {
+	return specialSchemes[scheme]
+}
+
+// percent-encode sets of the WHATWG URL standard, the C0 control set and everything above `~` is always encoded
+const (
+	fragmentEncodeSet = " \"<>`"
+	queryEncodeSet    = " \"#<>'"
+	pathEncodeSet     = " \"#<>?^`{}"
+	userinfoEncodeSet = " \"#<>?^`{}/:;=@[\\]|"
+)
+
+// segmentEncodeSet escapes everything that would end a path segment or be read as an escape
+const segmentEncodeSet = pathEncodeSet + "/\\%"
+
+func percentEncode(s, set string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c > 0x1f && c < 0x7f && strings.IndexByte(set, c) < 0 {
+			if b != nil {
+				b = append(b, c)
+			}
+			continue
+		}
+		if b == nil {
+			b = append(make([]byte, 0, len(s)+8), s[:i]...)
+		}
+		const upperHex = "0123456789ABCDEF"
+		b = append(b, '%', upperHex[c>>4], upperHex[c&15])
+	}
+	if b == nil {
+		return s
+	}
+	return string(b)
+}
+
+// forbiddenHostCodePoints are rejected in hostnames by the WHATWG URL standard
+const forbiddenHostCodePoints = "\x00\t\n\r #/:<>?@[\\]^|%"
+
+var hostnameProfile = idna.New(idna.MapForLookup(), idna.Transitional(false), idna.CheckHyphens(false), idna.StrictDomainName(false))
+
+func canonicalizeHostname(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	if s[0] == '[' {
+		return canonicalizeIPv6Hostname(s)
+	}
+	if strings.ContainsAny(s, forbiddenHostCodePoints) {
+		return "", errors.New("forbidden host code point")
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return hostnameProfile.ToASCII(s)
+		}
+	}
+	return strings.ToLower(s), nil
+}
+
+func canonicalizeIPv6Hostname(s string) (string, error) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !isHex(c) && c != '[' && c != ']' && c != ':' {
+			return "", errors.New("invalid IPv6 hostname")
+		}
+	}
+	return strings.ToLower(s), nil
+}
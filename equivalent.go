@@ -0,0 +1,76 @@
+package fasturl
+
+import (
+	"sort"
+	"strings"
+)
+
+// CompareLevel is a rung of the comparison ladder of RFC 3986 section 6.2, each level includes the ones before it
+type CompareLevel int
+
+const (
+	// CompareExact compares the components as they were parsed
+	CompareExact CompareLevel = iota
+	// CompareCase ignores the case of the scheme, the host and the hex digits of percent-encodings
+	CompareCase
+	// ComparePercentEncoding also decodes percent-encoded unreserved characters, so "%7E" equals "~"
+	ComparePercentEncoding
+	// ComparePathSegments also removes the dot segments of the path
+	ComparePathSegments
+	// CompareScheme also drops default ports and treats an empty path as "/" when there is a host
+	CompareScheme
+)
+
+// CompareOptions configures `Equivalent`
+type CompareOptions struct {
+	Level CompareLevel
+	// IgnoreFragment compares URLs regardless of their fragment
+	IgnoreFragment bool
+	// IgnoreQueryOrder compares query parameters as a multiset, so "a=1&b=2" equals "b=2&a=1"
+	IgnoreQueryOrder bool
+	// IgnoreTrailingSlash treats "/a/" as "/a" and "/" as an empty path
+	IgnoreTrailingSlash bool
+}
+
+// Equivalent reports whether a and b identify the same resource at the strictness of opts
+func Equivalent(a, b *URL, opts CompareOptions) bool {
+	return comparisonForm(a, opts) == comparisonForm(b, opts)
+}
+
+// comparisonForm returns a copy of u with everything opts ignores normalized away
+func comparisonForm(u *URL, opts CompareOptions) URL {
+	c := *u
+	if opts.Level >= CompareCase {
+		decode := opts.Level >= ComparePercentEncoding
+		c.Protocol = strings.ToLower(c.Protocol)
+		c.User = rewritePercentEncoding(c.User, decode)
+		c.Host = lowerOutsideEscapes(rewritePercentEncoding(c.Host, decode))
+		c.Path = rewritePercentEncoding(c.Path, decode)
+		c.Query = rewritePercentEncoding(c.Query, decode)
+		c.Fragment = rewritePercentEncoding(c.Fragment, decode)
+	}
+	if opts.Level >= ComparePathSegments {
+		c.Path = removeDotSegments(c.Path)
+	}
+	if opts.Level >= CompareScheme {
+		if c.Port != "" && defaultPorts[c.Protocol] == c.Port {
+			c.Port = ""
+		}
+		if c.Path == "" && c.Host != "" {
+			c.Path = "/"
+		}
+	}
+
+	if opts.IgnoreFragment {
+		c.Fragment = ""
+	}
+	if opts.IgnoreQueryOrder && strings.IndexByte(c.Query, '&') >= 0 {
+		params := strings.Split(c.Query, "&")
+		sort.Strings(params)
+		c.Query = strings.Join(params, "&")
+	}
+	if opts.IgnoreTrailingSlash {
+		c.Path = strings.TrimSuffix(c.Path, "/")
+	}
+	return c
+}
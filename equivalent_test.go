@@ -0,0 +1,63 @@
+package fasturl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEquivalent(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		// level is the lowest level at which a and b are equivalent
+		level CompareLevel
+	}{
+		{"http://example.com/a", "http://example.com/a", CompareExact},
+		{"HTTP://Example.COM/a", "http://example.com/a", CompareCase},
+		{"http://example.com/%7e", "http://example.com/%7E", CompareCase},
+		{"http://example.com/%7Euser", "http://example.com/~user", ComparePercentEncoding},
+		{"http://%45xample.com/", "http://example.com/", ComparePercentEncoding},
+		{"http://example.com/a/./b/../c", "http://example.com/a/c", ComparePathSegments},
+		{"HTTP://Example.com:80/a/./b", "http://example.com/a/b", CompareScheme},
+		{"https://example.com", "https://example.com:443/", CompareScheme},
+	} {
+		a, b := mustParse(t, tc.a), mustParse(t, tc.b)
+		for level := CompareExact; level <= CompareScheme; level++ {
+			assert.Equal(t, level >= tc.level, Equivalent(a, b, CompareOptions{Level: level}), "%s %s at level %d", tc.a, tc.b, level)
+		}
+	}
+
+	t.Run("Never equivalent", func(t *testing.T) {
+		for _, tc := range []struct{ a, b string }{
+			{"http://example.com/a", "https://example.com/a"},
+			{"http://example.com/A", "http://example.com/a"},
+			{"http://example.com:8080/", "http://example.com/"},
+			{"http://user@example.com/", "http://USER@example.com/"},
+			{"http://example.com/a%2Fb", "http://example.com/a/b"},
+			{"mailto:a@example.com", "mailto://a@example.com"},
+		} {
+			assert.False(t, Equivalent(mustParse(t, tc.a), mustParse(t, tc.b), CompareOptions{Level: CompareScheme}), tc.a)
+		}
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		for _, tc := range []struct {
+			a, b string
+			opts CompareOptions
+		}{
+			{"http://example.com/a#x", "http://example.com/a#y", CompareOptions{IgnoreFragment: true}},
+			{"http://example.com/a#x", "http://example.com/a", CompareOptions{IgnoreFragment: true}},
+			{"http://example.com/?a=1&b=2&a=0", "http://example.com/?a=0&a=1&b=2", CompareOptions{IgnoreQueryOrder: true}},
+			{"http://example.com/a/", "http://example.com/a", CompareOptions{IgnoreTrailingSlash: true}},
+			{"http://example.com/", "http://example.com", CompareOptions{IgnoreTrailingSlash: true}},
+			{"HTTP://example.com:80/a/?b=%7e&a#f", "http://example.com/a?a&b=~", CompareOptions{Level: CompareScheme, IgnoreFragment: true, IgnoreQueryOrder: true, IgnoreTrailingSlash: true}},
+		} {
+			a, b := mustParse(t, tc.a), mustParse(t, tc.b)
+			assert.True(t, Equivalent(a, b, tc.opts), tc.a)
+			assert.False(t, Equivalent(a, b, CompareOptions{Level: tc.opts.Level}), tc.a)
+		}
+
+		a, b := mustParse(t, "http://example.com/?a=1&a=2"), mustParse(t, "http://example.com/?a=1")
+		assert.False(t, Equivalent(a, b, CompareOptions{IgnoreQueryOrder: true}))
+	})
+}
@@ -0,0 +1,332 @@
+package extract
+
+import (
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ImVexed/fasturl"
+)
+
+// cssRef is a url() or @import reference found in a stylesheet
+type cssRef struct {
+	// raw is the reference with its escapes decoded
+	raw string
+	// start and end delimit the string token, quotes included, or the value of an unquoted url() in the stylesheet
+	start, end int
+	// quote is the quote of a string token, 0 for an unquoted url()
+	quote byte
+	// imp is set for the target of an @import rule
+	imp bool
+}
+
+// CSS reads the stylesheet from r and calls fn for every url() and @import reference it holds, until fn returns an error.
+// References are resolved against base, which may be nil, and tokenized following CSS Syntax Level 3
+// so that comments, escapes and quotes are handled as browsers do.
+func CSS(r io.Reader, base *fasturl.URL, fn func(Link) error) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return scanCSS(string(data), func(ref cssRef) error {
+		return fn(cssLink("", "", ref, base))
+	})
+}
+
+// RewriteCSS returns css with every url() and @import reference replaced by the reference fn returns for it.
+// A reference fn returns unchanged is kept as written, others are escaped and keep the quotes of the original.
+func RewriteCSS(css string, base *fasturl.URL, fn func(Link) string) string {
+	var sb strings.Builder
+	last, changed := 0, false
+	scanCSS(css, func(ref cssRef) error {
+		l := cssLink("", "", ref, base)
+		replacement := fn(l)
+		if replacement == l.Raw {
+			return nil
+		}
+		changed = true
+		sb.WriteString(css[last:ref.start])
+		if ref.quote != 0 {
+			sb.WriteByte(ref.quote)
+			writeCSSEscaped(&sb, replacement, ref.quote)
+			sb.WriteByte(ref.quote)
+		} else {
+			writeCSSEscaped(&sb, replacement, 0)
+		}
+		last = ref.end
+		return nil
+	})
+	if !changed {
+		return css
+	}
+	sb.WriteString(css[last:])
+	return sb.String()
+}
+
+func cssLink(tag, attr string, ref cssRef, base *fasturl.URL) Link {
+	l := Link{Tag: tag, Attr: attr, Raw: trimURL(ref.raw), Import: ref.imp}
+	l.URL, l.Err = resolve(base, l.Raw)
+	return l
+}
+
+// writeCSSEscaped writes s escaped for a string token delimited by quote, or for an unquoted url() when quote is 0
+func writeCSSEscaped(sb *strings.Builder, s string, quote byte) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' || quote != 0 && c == quote || quote == 0 && (c == '"' || c == '\'' || c == '(' || c == ')'):
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < 0x20 || c == 0x7f || quote == 0 && c == ' ':
+			// a hex escape swallows the whitespace that follows it
+			sb.WriteByte('\\')
+			sb.WriteString(strconv.FormatInt(int64(c), 16))
+			sb.WriteByte(' ')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+// scanCSS tokenizes css just enough to find its url() and @import references and calls fn for each of them but the empty ones
+func scanCSS(css string, fn func(cssRef) error) error {
+	emit := func(ref cssRef) error {
+		if trimURL(ref.raw) == "" {
+			return nil
+		}
+		return fn(ref)
+	}
+	importPending := false
+	for i := 0; i < len(css); {
+		c := css[i]
+		switch {
+		case c == '/' && strings.HasPrefix(css[i:], "/*"):
+			// comments and whitespace don't separate @import from its target
+			if end := strings.Index(css[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(css)
+			}
+			continue
+		case isSpace(c):
+			i++
+			continue
+		case c == '"' || c == '\'':
+			value, end, ok := cssString(css, i)
+			if ok && importPending {
+				if err := emit(cssRef{value, i, end, c, true}); err != nil {
+					return err
+				}
+			}
+			i = end
+		case c == '@' && startsCSSIdent(css, i+1):
+			name, end := cssIdent(css, i+1)
+			i = end
+			importPending = strings.EqualFold(name, "import")
+			continue
+		case startsCSSIdent(css, i):
+			name, end := cssIdent(css, i)
+			i = end
+			if end < len(css) && css[end] == '(' && strings.EqualFold(name, "url") {
+				ref, next, ok := cssURL(css, end+1)
+				if ok {
+					ref.imp = importPending
+					if err := emit(ref); err != nil {
+						return err
+					}
+				}
+				i = next
+			}
+		case '0' <= c && c <= '9' || c == '#':
+			// numbers, dimensions and hashes, so that "2url(" or "#url(" aren't read as url()
+			i++
+			for i < len(css) && (isCSSName(css[i]) || css[i] == '.') {
+				i++
+			}
+		default:
+			i++
+		}
+		importPending = false
+	}
+	return nil
+}
+
+// cssURL reads the argument of a url() function starting right after its "("
+func cssURL(css string, i int) (ref cssRef, next int, ok bool) {
+	for i < len(css) && isSpace(css[i]) {
+		i++
+	}
+	if i < len(css) && (css[i] == '"' || css[i] == '\'') {
+		// url("...") is a function taking a string
+		value, end, ok := cssString(css, i)
+		j := end
+		for j < len(css) && isSpace(css[j]) {
+			j++
+		}
+		if !ok || j < len(css) && css[j] != ')' {
+			return cssRef{}, skipBadURL(css, j), false
+		}
+		return cssRef{raw: value, start: i, end: end, quote: css[i]}, skipBadURL(css, j), true
+	}
+
+	var sb strings.Builder
+	start := i
+	for i < len(css) {
+		c := css[i]
+		switch {
+		case c == ')':
+			return cssRef{raw: sb.String(), start: start, end: i}, i + 1, true
+		case isSpace(c):
+			end := i
+			for i < len(css) && isSpace(css[i]) {
+				i++
+			}
+			if i == len(css) || css[i] == ')' {
+				return cssRef{raw: sb.String(), start: start, end: end}, skipBadURL(css, i), true
+			}
+			return cssRef{}, skipBadURL(css, i), false
+		case c == '"' || c == '\'' || c == '(' || c < 0x20 || c == 0x7f:
+			return cssRef{}, skipBadURL(css, i), false
+		case c == '\\':
+			if !isValidEscape(css, i) {
+				return cssRef{}, skipBadURL(css, i), false
+			}
+			var r rune
+			r, i = cssEscape(css, i+1)
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	// the stylesheet ending inside url() is only a parse error
+	return cssRef{raw: sb.String(), start: start, end: i}, i, true
+}
+
+// skipBadURL skips the rest of a bad url() up to and including its closing parenthesis
+func skipBadURL(css string, i int) int {
+	for i < len(css) {
+		switch {
+		case css[i] == ')':
+			return i + 1
+		case isValidEscape(css, i):
+			_, i = cssEscape(css, i+1)
+		default:
+			i++
+		}
+	}
+	return i
+}
+
+// cssString reads the string token starting with the quote at i, ok is false for a string broken by a newline
+func cssString(css string, i int) (value string, end int, ok bool) {
+	quote := css[i]
+	var sb strings.Builder
+	for i++; i < len(css); {
+		c := css[i]
+		switch {
+		case c == quote:
+			return sb.String(), i + 1, true
+		case c == '\n' || c == '\r' || c == '\f':
+			return "", i, false
+		case c == '\\':
+			switch {
+			case i+1 == len(css):
+				i++
+			case css[i+1] == '\n' || css[i+1] == '\f':
+				i += 2
+			case css[i+1] == '\r':
+				i += 2
+				if i < len(css) && css[i] == '\n' {
+					i++
+				}
+			default:
+				var r rune
+				r, i = cssEscape(css, i+1)
+				sb.WriteRune(r)
+			}
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+	return sb.String(), i, true
+}
+
+// cssIdent reads the ident sequence starting at i and returns it with its escapes decoded
+func cssIdent(css string, i int) (string, int) {
+	var sb strings.Builder
+	for i < len(css) {
+		switch c := css[i]; {
+		case isCSSName(c):
+			sb.WriteByte(c)
+			i++
+		case isValidEscape(css, i):
+			var r rune
+			r, i = cssEscape(css, i+1)
+			sb.WriteRune(r)
+		default:
+			return sb.String(), i
+		}
+	}
+	return sb.String(), i
+}
+
+// cssEscape decodes the escape whose backslash precedes i
+func cssEscape(css string, i int) (rune, int) {
+	if i == len(css) {
+		return utf8.RuneError, i
+	}
+	if !isHex(css[i]) {
+		r, size := utf8.DecodeRuneInString(css[i:])
+		return r, i + size
+	}
+	start := i
+	for i < len(css) && i-start < 6 && isHex(css[i]) {
+		i++
+	}
+	n, _ := strconv.ParseUint(css[start:i], 16, 32)
+	if strings.HasPrefix(css[i:], "\r\n") {
+		i += 2
+	} else if i < len(css) && isSpace(css[i]) {
+		i++
+	}
+	if n == 0 || 0xD800 <= n && n <= 0xDFFF || n > utf8.MaxRune {
+		return utf8.RuneError, i
+	}
+	return rune(n), i
+}
+
+// isValidEscape reports whether a backslash at i starts an escape rather than being a parse error
+func isValidEscape(css string, i int) bool {
+	return i+1 < len(css) && css[i] == '\\' && css[i+1] != '\n' && css[i+1] != '\r' && css[i+1] != '\f'
+}
+
+// startsCSSIdent reports whether an ident sequence starts at i
+func startsCSSIdent(css string, i int) bool {
+	if i >= len(css) {
+		return false
+	}
+	switch c := css[i]; {
+	case c == '-':
+		return i+1 < len(css) && (isCSSNameStart(css[i+1]) || css[i+1] == '-' || isValidEscape(css, i+1))
+	case c == '\\':
+		return isValidEscape(css, i)
+	default:
+		return isCSSNameStart(c)
+	}
+}
+
+func isCSSNameStart(c byte) bool {
+	return isASCIIAlpha(c) || c == '_' || c >= 0x80
+}
+
+func isCSSName(c byte) bool {
+	return isCSSNameStart(c) || '0' <= c && c <= '9' || c == '-'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
@@ -0,0 +1,87 @@
+package extract
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSS(t *testing.T) {
+	const css = `@charset "utf-8";
+@import "reset.css";
+@IMPORT /* comment */ url(print.css) print;
+@import 'quoted\'s.css' screen;
+/* url(commented.png) */
+body { background: url( "img/bg.png" ) no-repeat; }
+.a { background: URL(img/a\).png); }
+.b { background: url(  img/b.png  ); content: "url(not-a-url.png)"; }
+.c { background: u\72l(img/c.png); }
+.d { background: myurl(img/d.png), 2url(img/e.png); }
+.e { background: url(img/bad url.png), url(img/"bad.png); }
+.f { background: url(""); mask: url(#mask); }
+.g { background: url('img/g\
+.png'); }
+.h { content: "broken
+; }
+.h2 { background: url(img/h%20\2e png); }
+@font-face { src: url(data:font/woff2;base64,AAAA) format("woff2"), url(//cdn.example.net/f.woff); }
+.i { background: url(img/i.png`
+
+	var links []string
+	err := CSS(strings.NewReader(css), mustParse(t, "https://example.com/css/site.css"), func(l Link) error {
+		require.NoError(t, l.Err, l.Raw)
+		s := l.URL.String()
+		if l.Import {
+			s = "@import " + s
+		}
+		links = append(links, s)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"@import https://example.com/css/reset.css",
+		"@import https://example.com/css/print.css",
+		"@import https://example.com/css/quoted's.css",
+		"https://example.com/css/img/bg.png",
+		"https://example.com/css/img/a).png",
+		"https://example.com/css/img/b.png",
+		"https://example.com/css/img/c.png",
+		"https://example.com/css/site.css#mask",
+		"https://example.com/css/img/g.png",
+		"https://example.com/css/img/h%20.png",
+		"data:font/woff2;base64,AAAA",
+		"https://cdn.example.net/f.woff",
+		"https://example.com/css/img/i.png",
+	}, links)
+}
+
+func TestRewriteCSS(t *testing.T) {
+	const css = `@import "a.css"; body { background: url( b.png ) } .x { background: url('c.png'); mask: url(keep.png) }`
+	mirror := map[string]string{
+		"https://example.com/a.css": "/mirror/a.css",
+		"https://example.com/b.png": "/mirror/b (1).png",
+		"https://example.com/c.png": "/mirror/c's.png",
+	}
+	var raws []string
+	out := RewriteCSS(css, mustParse(t, "https://example.com/"), func(l Link) string {
+		raws = append(raws, l.Raw)
+		if m, ok := mirror[l.URL.String()]; ok {
+			return m
+		}
+		return l.Raw
+	})
+	assert.Equal(t, []string{"a.css", "b.png", "c.png", "keep.png"}, raws)
+	assert.Equal(t, `@import "/mirror/a.css"; body { background: url( /mirror/b\20 \(1\).png ) } .x { background: url('/mirror/c\'s.png'); mask: url(keep.png) }`, out)
+
+	var rewritten []string
+	require.NoError(t, CSS(strings.NewReader(out), nil, func(l Link) error {
+		rewritten = append(rewritten, l.Raw)
+		return nil
+	}))
+	assert.Equal(t, []string{"/mirror/a.css", "/mirror/b (1).png", "/mirror/c's.png", "keep.png"}, rewritten)
+
+	unchanged := RewriteCSS(css, nil, func(l Link) string { return l.Raw })
+	assert.Equal(t, css, unchanged)
+}
@@ -0,0 +1,435 @@
+// Package extract finds the URLs referenced by documents, resolving them with fasturl.
+// It relies on small hand-written tokenizers rather than full parsers, so it only depends on the standard library.
+package extract
+
+import (
+	"bufio"
+	"errors"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/ImVexed/fasturl"
+)
+
+// Link is a URL referenced by a document
+type Link struct {
+	// Tag is the lower cased name of the element the URL was found in, empty in a stylesheet
+	Tag string
+	// Attr is the lower cased name of the attribute the URL was found in, empty in a stylesheet or a <style> element
+	Attr string
+	// Raw is the reference as written, with character references decoded and surrounding whitespace removed
+	Raw string
+	// URL is Raw resolved against the base URL of the document, nil when Err is set
+	URL *fasturl.URL
+	// Err is set when Raw doesn't parse or is relative to a document without a URL
+	Err error
+	// Import is set for the target of a CSS @import rule, which is a stylesheet
+	Import bool
+}
+
+// urlAttrs are the attributes holding a single URL on any element
+var urlAttrs = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"action": true,
+	"poster": true,
+}
+
+// rawTextElements are the elements whose content isn't markup
+var rawTextElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"textarea": true,
+	"title":    true,
+	"xmp":      true,
+	"iframe":   true,
+	"noembed":  true,
+	"noframes": true,
+}
+
+// HTML reads the document from r and calls fn for every URL it references in document order, until fn returns an error.
+// Besides URL attributes, srcset candidates and <meta http-equiv=refresh>, the url() and @import references
+// of style attributes and <style> elements are reported, see `CSS`. References are resolved against the first <base href> of the document, itself resolved against docURL,
+// and against docURL alone when there is none yet. docURL may be nil, relative references then fail to resolve.
+// The document is streamed and the first <base href> applies to the links that follow it only.
+func HTML(r io.Reader, docURL *fasturl.URL, fn func(Link) error) error {
+	base, hasBase := docURL, false
+	emit := func(tag, attr, raw string) error {
+		raw = trimURL(raw)
+		if raw == "" {
+			return nil
+		}
+		l := Link{Tag: tag, Attr: attr, Raw: raw}
+		l.URL, l.Err = resolve(base, raw)
+		return fn(l)
+	}
+
+	emitCSS := func(tag, attr, css string) error {
+		return scanCSS(css, func(ref cssRef) error {
+			return fn(cssLink(tag, attr, ref, base))
+		})
+	}
+
+	return tokenizeHTML(r, func(tag string, attrs []htmlAttr, text string) error {
+		if tag == "base" && !hasBase {
+			if href, ok := attrValue(attrs, "href"); ok {
+				hasBase = true
+				if u, err := resolve(docURL, trimURL(href)); err == nil {
+					base = u
+				}
+			}
+		}
+
+		for _, a := range attrs {
+			var err error
+			switch {
+			case urlAttrs[a.name]:
+				err = emit(tag, a.name, a.value)
+			case a.name == "data" && tag == "object":
+				err = emit(tag, a.name, a.value)
+			case a.name == "srcset":
+				for _, candidate := range parseSrcset(a.value) {
+					if err = emit(tag, a.name, candidate); err != nil {
+						break
+					}
+				}
+			case a.name == "content" && tag == "meta":
+				if equiv, _ := attrValue(attrs, "http-equiv"); strings.EqualFold(strings.TrimSpace(equiv), "refresh") {
+					if ref, ok := parseRefresh(a.value); ok {
+						err = emit(tag, a.name, ref)
+					}
+				}
+			case a.name == "style":
+				err = emitCSS(tag, a.name, a.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if tag == "style" {
+			return emitCSS(tag, "", text)
+		}
+		return nil
+	})
+}
+
+var errNoBase = errors.New("extract: relative reference without a base URL")
+
+func resolve(base *fasturl.URL, raw string) (*fasturl.URL, error) {
+	if base != nil {
+		return base.Resolve(raw)
+	}
+	u, err := fasturl.ParseURL(raw)
+	if err == nil && u.Protocol == "" {
+		return nil, errNoBase
+	}
+	return u, err
+}
+
+// trimURL removes the surrounding whitespace of an attribute URL and the tabs and newlines within it, as URL parsers do
+func trimURL(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, "\t\n\r") {
+		s = strings.NewReplacer("\t", "", "\n", "", "\r", "").Replace(s)
+	}
+	return s
+}
+
+func attrValue(attrs []htmlAttr, name string) (string, bool) {
+	for _, a := range attrs {
+		if a.name == name {
+			return a.value, true
+		}
+	}
+	return "", false
+}
+
+// parseSrcset returns the URLs of the image candidates of a srcset attribute, see the HTML standard's
+// "parse a srcset attribute" algorithm
+func parseSrcset(s string) []string {
+	var urls []string
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return urls
+		}
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end < 0 {
+			end = len(s)
+		}
+		u := s[:end]
+		s = s[end:]
+		if strings.HasSuffix(u, ",") {
+			// a URL ending with commas has no descriptors
+			urls = append(urls, strings.TrimRight(u, ","))
+			continue
+		}
+		urls = append(urls, u)
+
+		// skip the descriptors, up to a comma that isn't inside parentheses
+		depth := 0
+		i := 0
+		for ; i < len(s); i++ {
+			if s[i] == '(' {
+				depth++
+			} else if s[i] == ')' && depth > 0 {
+				depth--
+			} else if s[i] == ',' && depth == 0 {
+				break
+			}
+		}
+		s = s[i:]
+	}
+}
+
+// parseRefresh returns the URL of the content attribute of a <meta http-equiv=refresh>, see the HTML standard's
+// "shared declarative refresh steps"
+func parseRefresh(s string) (string, bool) {
+	s = strings.TrimLeft(s, " \t\n\r\f")
+	i := 0
+	for i < len(s) && ('0' <= s[i] && s[i] <= '9' || s[i] == '.') {
+		i++
+	}
+	if i == 0 {
+		return "", false
+	}
+	s = strings.TrimLeft(s[i:], " \t\n\r\f")
+	if s == "" || s[0] != ';' && s[0] != ',' {
+		return "", false
+	}
+	s = strings.TrimLeft(s[1:], " \t\n\r\f")
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		if rest := strings.TrimLeft(s[3:], " \t\n\r\f"); strings.HasPrefix(rest, "=") {
+			s = strings.TrimLeft(rest[1:], " \t\n\r\f")
+		}
+	}
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		if i := strings.IndexByte(s[1:], s[0]); i >= 0 {
+			s = s[1 : i+1]
+		} else {
+			s = s[1:]
+		}
+	}
+	return s, s != ""
+}
+
+type htmlAttr struct {
+	name, value string
+}
+
+// tokenizeHTML calls fn with the lower cased name and the attributes of every start tag of the document,
+// and with the content of the element for <style>. Comments, doctypes, end tags and the content of other
+// raw text elements such as <script> are skipped.
+func tokenizeHTML(r io.Reader, fn func(tag string, attrs []htmlAttr, text string) error) error {
+	z := htmlTokenizer{r: bufio.NewReader(r)}
+	var attrs []htmlAttr
+	for {
+		if err := z.skipPast("<", false, nil); err != nil {
+			return z.result(err)
+		}
+		c, err := z.r.ReadByte()
+		if err != nil {
+			return z.result(err)
+		}
+
+		switch {
+		case c == '!':
+			if next, _ := z.r.Peek(2); string(next) == "--" {
+				z.r.Discard(2)
+				err = z.skipPast("-->", false, nil)
+			} else {
+				err = z.skipPast(">", false, nil)
+			}
+		case c == '?' || c == '/':
+			err = z.skipPast(">", false, nil)
+		case c == '<':
+			z.r.UnreadByte()
+		case isASCIIAlpha(c):
+			z.r.UnreadByte()
+			var name string
+			var closed bool
+			name, attrs, closed, err = z.readTag(attrs[:0])
+			switch {
+			case err != nil || !closed:
+			case name == "style":
+				var text []byte
+				if text, err = z.readRawText(name); err == nil || err == io.EOF {
+					err = fn(name, attrs, string(text))
+				}
+			default:
+				if err = fn(name, attrs, ""); err == nil && rawTextElements[name] {
+					err = z.skipPast("</"+name, true, nil)
+				}
+			}
+			if err == nil && name == "plaintext" {
+				// the rest of the document is text
+				return nil
+			}
+		}
+		if err != nil {
+			return z.result(err)
+		}
+	}
+}
+
+type htmlTokenizer struct {
+	r *bufio.Reader
+}
+
+// result turns the end of the input into a successful end of the document
+func (z *htmlTokenizer) result(err error) error {
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+// readRawText reads the content of a raw text element and its end tag
+func (z *htmlTokenizer) readRawText(name string) ([]byte, error) {
+	var text []byte
+	end := "</" + name
+	err := z.skipPast(end, true, &text)
+	if err == nil {
+		text = text[:len(text)-len(end)]
+	}
+	return text, err
+}
+
+// skipPast reads up to and including seq, ignoring ASCII case if fold is set, and appends what it reads to text if not nil.
+// A "</name" sequence only matches when it ends the name, and the rest of the end tag is skipped too.
+func (z *htmlTokenizer) skipPast(seq string, fold bool, text *[]byte) error {
+	matched := 0
+	for {
+		c, err := z.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if text != nil {
+			*text = append(*text, c)
+		}
+		if fold {
+			c = toLower(c)
+		}
+		switch {
+		case c == seq[matched]:
+			matched++
+		case seq == "-->" && matched == 2 && c == '-':
+			// "--->" still ends a comment
+		case c == seq[0]:
+			matched = 1
+		default:
+			matched = 0
+		}
+		if matched < len(seq) {
+			continue
+		}
+		if !strings.HasPrefix(seq, "</") {
+			return nil
+		}
+		next, err := z.r.Peek(1)
+		if err != nil || isSpace(next[0]) || next[0] == '/' || next[0] == '>' {
+			return z.skipPast(">", false, nil)
+		}
+		matched = 0
+	}
+}
+
+// readTag reads a start tag after its "<", closed is false when the input ends inside it
+func (z *htmlTokenizer) readTag(attrs []htmlAttr) (name string, _ []htmlAttr, closed bool, err error) {
+	name, err = z.readName(false)
+	if err != nil {
+		return name, attrs, false, err
+	}
+	for {
+		c, err := z.skipSpace()
+		if err != nil {
+			return name, attrs, false, err
+		}
+		switch c {
+		case '>':
+			return name, attrs, true, nil
+		case '/':
+			continue
+		}
+		z.r.UnreadByte()
+
+		attrName, err := z.readName(true)
+		if err != nil {
+			return name, attrs, false, err
+		}
+		c, err = z.skipSpace()
+		if err != nil {
+			return name, attrs, false, err
+		}
+		if c != '=' {
+			z.r.UnreadByte()
+			attrs = append(attrs, htmlAttr{attrName, ""})
+			continue
+		}
+		if c, err = z.skipSpace(); err != nil {
+			return name, attrs, false, err
+		}
+		var value []byte
+		if c == '"' || c == '\'' {
+			if value, err = z.r.ReadBytes(c); err != nil {
+				return name, attrs, false, err
+			}
+			value = value[:len(value)-1]
+		} else {
+			z.r.UnreadByte()
+			for {
+				if c, err = z.r.ReadByte(); err != nil {
+					return name, attrs, false, err
+				}
+				if isSpace(c) || c == '>' {
+					z.r.UnreadByte()
+					break
+				}
+				value = append(value, c)
+			}
+		}
+		attrs = append(attrs, htmlAttr{attrName, html.UnescapeString(string(value))})
+	}
+}
+
+// readName reads a lower cased tag or attribute name, an attribute name also ends at "="
+func (z *htmlTokenizer) readName(attr bool) (string, error) {
+	var sb strings.Builder
+	for {
+		c, err := z.r.ReadByte()
+		if err != nil {
+			return sb.String(), err
+		}
+		if isSpace(c) || c == '/' || c == '>' || attr && c == '=' && sb.Len() > 0 {
+			z.r.UnreadByte()
+			return sb.String(), nil
+		}
+		sb.WriteByte(toLower(c))
+	}
+}
+
+// skipSpace returns the first byte that isn't whitespace
+func (z *htmlTokenizer) skipSpace() (byte, error) {
+	for {
+		c, err := z.r.ReadByte()
+		if err != nil || !isSpace(c) {
+			return c, err
+		}
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func isASCIIAlpha(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func toLower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
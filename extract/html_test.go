@@ -0,0 +1,146 @@
+package extract
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ImVexed/fasturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, s string) *fasturl.URL {
+	u, err := fasturl.ParseURL(s)
+	require.NoError(t, err)
+	return u
+}
+
+// extractHTML returns the links of doc as "tag attr url" strings
+func extractHTML(t *testing.T, doc, docURL string) []string {
+	var base *fasturl.URL
+	if docURL != "" {
+		base = mustParse(t, docURL)
+	}
+	var links []string
+	err := HTML(strings.NewReader(doc), base, func(l Link) error {
+		if l.Err != nil {
+			links = append(links, l.Tag+" "+l.Attr+" error "+l.Raw)
+		} else {
+			links = append(links, l.Tag+" "+l.Attr+" "+l.URL.String())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return links
+}
+
+func TestHTML(t *testing.T) {
+	const doc = `<!DOCTYPE html>
+<html>
+<head>
+  <!-- <a href="/commented"> -->
+  <link rel=stylesheet href="css/site.css">
+  <BASE HREF="/static/">
+  <base href="/ignored/">
+  <meta http-equiv="Refresh" content="5; URL='../next.html'">
+  <meta name="description" content="5; url=/not-a-refresh">
+  <script src="app.js">document.write('<a href="/in-script">')</script>
+  <style>p { background: url(/in-style-element.png) }</style>
+  <title>A <a href="/in-title"> title</title>
+</head>
+<body style="background-image: url( 'bg.png' ); border-image: URL(border.svg)">
+  <a href="https://other.example/?a=1&amp;b=2#top">other</a>
+  <a href=page.html title=x>unquoted</a>
+  <a href="
+     spaced.html ">spaced</a>
+  <a href="">empty</a>
+  <img src="img/a.png" srcset="img/a-1x.png 1x, img/a-2x.png 2x,img/a,3x.png, data:image/png;base64,AA== 3x">
+  <picture><source srcset="wide.webp 800w , narrow.webp 400w"></picture>
+  <form action="/search" method=get><input type=submit></form>
+  <video poster="poster.jpg" src=movie.mp4></video>
+  <object data="movie.swf"></object>
+  <div data="not-an-object"></div>
+  <a href="#section">fragment</a>
+  <a href="//cdn.example.net/lib.js">protocol relative</a>
+  <a href="http://[::1">unparsable</a>
+  <a href="/login?next=/home">query with a path</a>
+  <a href="https://www.google.com/url?q=https://x.com/">query with a URL</a>
+  <textarea><a href="/in-textarea"></textarea>
+  <<a href="after-lt.html">
+  <a href="last.html"
+</body>
+</html>`
+
+	assert.Equal(t, []string{
+		"link href https://example.com/docs/css/site.css",
+		"base href https://example.com/static/",
+		"base href https://example.com/ignored/",
+		"meta content https://example.com/next.html",
+		"script src https://example.com/static/app.js",
+		"style  https://example.com/in-style-element.png",
+		"body style https://example.com/static/bg.png",
+		"body style https://example.com/static/border.svg",
+		"a href https://other.example/?a=1&b=2#top",
+		"a href https://example.com/static/page.html",
+		"a href https://example.com/static/spaced.html",
+		"img src https://example.com/static/img/a.png",
+		"img srcset https://example.com/static/img/a-1x.png",
+		"img srcset https://example.com/static/img/a-2x.png",
+		"img srcset https://example.com/static/img/a,3x.png",
+		"img srcset data:image/png;base64,AA==",
+		"source srcset https://example.com/static/wide.webp",
+		"source srcset https://example.com/static/narrow.webp",
+		"form action https://example.com/search",
+		"video poster https://example.com/static/poster.jpg",
+		"video src https://example.com/static/movie.mp4",
+		"object data https://example.com/static/movie.swf",
+		"a href https://example.com/static/#section",
+		"a href https://cdn.example.net/lib.js",
+		"a href error http://[::1",
+		"a href https://example.com/login?next=/home",
+		"a href https://www.google.com/url?q=https://x.com/",
+		"a href https://example.com/static/after-lt.html",
+		"a href https://example.com/static/last.html",
+	}, extractHTML(t, doc, "https://example.com/docs/index.html"))
+}
+
+func TestHTMLWithoutBase(t *testing.T) {
+	links := extractHTML(t, `<a href="https://example.com/a"><a href="/relative"><IMG SRC='x.png'/>`, "")
+	assert.Equal(t, []string{"a href https://example.com/a", "a href error /relative", "img src error x.png"}, links)
+}
+
+func TestHTMLCallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	n := 0
+	err := HTML(strings.NewReader(`<a href="/a"><a href="/b">`), nil, func(Link) error {
+		n++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, n)
+}
+
+func TestParseSrcset(t *testing.T) {
+	assert.Equal(t, []string{"a.png", "b.png"}, parseSrcset("a.png 1x, b.png 2x"))
+	assert.Equal(t, []string{"a.png", "b.png"}, parseSrcset(" ,a.png, b.png"))
+	assert.Equal(t, []string{"a.png", "b.png"}, parseSrcset("a.png (max-width: 10px, 1x), b.png"))
+	assert.Empty(t, parseSrcset(" , "))
+}
+
+func TestParseRefresh(t *testing.T) {
+	for _, tc := range []struct {
+		content, url string
+	}{
+		{"0; url=/next", "/next"},
+		{"0;URL = \"/next\"", "/next"},
+		{"3.5, /next", "/next"},
+		{"0; url='/next", "/next"},
+		{"10", ""},
+		{"url=/next", ""},
+	} {
+		url, ok := parseRefresh(tc.content)
+		assert.Equal(t, tc.url, url, tc.content)
+		assert.Equal(t, tc.url != "", ok, tc.content)
+	}
+}
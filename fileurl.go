@@ -0,0 +1,163 @@
+package fasturl
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// PathStyle selects the file system path semantics of `FileURLToPath` and `PathToFileURL`
+type PathStyle int
+
+const (
+	// PosixPath paths are separated by "/" and absolute when they start with one
+	PosixPath PathStyle = iota
+	// WindowsPath paths are separated by "\" or "/" and absolute when they start with a drive, as in `C:\dir`,
+	// or are UNC paths, as in `\\server\share\dir`
+	WindowsPath
+)
+
+// NativePathStyle is the PathStyle of the operating system the program runs on
+var NativePathStyle = func() PathStyle {
+	if runtime.GOOS == "windows" {
+		return WindowsPath
+	}
+	return PosixPath
+}()
+
+var errInvalidFileURL = errors.New("fasturl: invalid file URL")
+
+func fileURLError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{errInvalidFileURL}, args...)...)
+}
+
+// FileURLToPath returns the absolute file system path u refers to following RFC 8089, its query and fragment are ignored.
+// A "localhost" host stands for the local machine, while other hosts are only allowed with `WindowsPath`,
+// which maps them to UNC paths. Drive letters may be followed by ":" or the legacy "|".
+func FileURLToPath(u *URL, style PathStyle) (string, error) {
+	if !strings.EqualFold(u.Protocol, "file") {
+		return "", fileURLError("scheme %q isn't file", u.Protocol)
+	}
+	if u.User != "" || u.Port != "" {
+		return "", fileURLError("%q has userinfo or a port", u.String())
+	}
+	host := u.Host
+	if strings.EqualFold(host, "localhost") {
+		host = ""
+	}
+
+	separator := "/"
+	if style == WindowsPath {
+		separator = `\`
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, seg := range segments {
+		decoded, err := percentDecodeSegment(seg, style)
+		if err != nil {
+			return "", err
+		}
+		segments[i] = decoded
+	}
+	path := strings.Join(segments, separator)
+
+	if style != WindowsPath {
+		switch {
+		case host != "":
+			return "", fileURLError("host %q isn't local", u.Host)
+		case path == "":
+			return "/", nil
+		case path[0] != '/':
+			return "", fileURLError("path %q isn't absolute", u.Path)
+		}
+		return path, nil
+	}
+
+	if host != "" {
+		if len(segments) < 2 || segments[0] != "" || segments[1] == "" {
+			return "", fileURLError("UNC path on %q without a share", u.Host)
+		}
+		return `\\` + host + path, nil
+	}
+	// path is `\C:\dir`, or `\C:` for the root of the drive
+	if len(path) < 3 || path[0] != '\\' || !isASCIIAlpha(path[1]) || path[2] != ':' && path[2] != '|' ||
+		len(path) > 3 && path[3] != '\\' {
+		return "", fileURLError("path %q doesn't start with a drive", u.Path)
+	}
+	if len(path) == 3 {
+		return path[1:2] + `:\`, nil
+	}
+	return path[1:2] + ":" + path[3:], nil
+}
+
+// percentDecodeSegment decodes a path segment, failing on encoded NULs and separators that would change the path
+func percentDecodeSegment(seg string, style PathStyle) (string, error) {
+	if strings.IndexByte(seg, '%') < 0 {
+		return seg, nil
+	}
+	var sb strings.Builder
+	dec := percentDecoder{seg}
+	for c, ok := dec.next(); ok; c, ok = dec.next() {
+		if c == 0 || c == '/' || c == '\\' && style == WindowsPath {
+			return "", fileURLError("path segment %q encodes %q", seg, c)
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), nil
+}
+
+// PathToFileURL returns the file URL of an absolute file system path following RFC 8089, percent-encoding what has to be.
+// A UNC path becomes a URL with the server as its host. A relative path, which has no file URL of its own,
+// becomes a relative reference to be resolved against the URL of its base directory.
+func PathToFileURL(path string, style PathStyle) *URL {
+	if style == WindowsPath {
+		path = strings.ReplaceAll(path, `\`, "/")
+		// long paths such as `\\?\C:\dir` and `\\?\UNC\server\share\dir`
+		if strings.HasPrefix(path, "//?/") {
+			path = path[4:]
+			if len(path) > 3 && strings.EqualFold(path[:4], "UNC/") {
+				path = "//" + path[4:]
+			}
+		}
+		switch {
+		case strings.HasPrefix(path, "//"):
+			host := path[2:]
+			rest := ""
+			if i := strings.IndexByte(host, '/'); i >= 0 {
+				host, rest = host[:i], host[i:]
+			}
+			return &URL{Protocol: "file", Host: host, Path: encodeFilePath(rest)}
+		case len(path) >= 2 && isASCIIAlpha(path[0]) && path[1] == ':' && (len(path) == 2 || path[2] == '/'):
+			if len(path) == 2 {
+				path += "/"
+			}
+			return &URL{Protocol: "file", Path: "/" + path[:2] + encodeFilePath(path[2:])}
+		case strings.HasPrefix(path, "/"):
+			// rooted on the current drive
+			return &URL{Path: encodeFilePath(path)}
+		}
+	} else if strings.HasPrefix(path, "/") {
+		// a path starting with "//" would be read as an authority, and POSIX lets systems treat it as "/"
+		return &URL{Protocol: "file", Path: encodeFilePath("/" + strings.TrimLeft(path, "/"))}
+	}
+
+	path = encodeFilePath(path)
+	if i := strings.IndexAny(path, ":/"); i >= 0 && path[i] == ':' {
+		// a colon in the first segment would be read as the end of a scheme
+		path = "./" + path
+	}
+	return &URL{Path: path}
+}
+
+// encodeFilePath percent-encodes every segment of a "/" separated path
+func encodeFilePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		segments[i] = percentEncode(seg, segmentEncodeSet+"[]|")
+	}
+	return strings.Join(segments, "/")
+}
+
+func isASCIIAlpha(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,115 @@
+package fasturl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileURLToPath(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		style PathStyle
+		want  string
+	}{
+		{"file:///etc/passwd", PosixPath, "/etc/passwd"},
+		{"file://localhost/etc/passwd", PosixPath, "/etc/passwd"},
+		{"FILE://LOCALHOST/etc/passwd", PosixPath, "/etc/passwd"},
+		{"file:/etc/passwd", PosixPath, "/etc/passwd"},
+		{"file:///home/a%20b/%E2%82%AC.txt?q#frag", PosixPath, "/home/a b/€.txt"},
+		{"file:///dir/back%5Cslash", PosixPath, `/dir/back\slash`},
+		{"file:///", PosixPath, "/"},
+		{"file://", PosixPath, "/"},
+		{"file:///C:/Program%20Files/x.exe", WindowsPath, `C:\Program Files\x.exe`},
+		{"file://localhost/c:/dir/", WindowsPath, `c:\dir\`},
+		{"file:///c|/legacy", WindowsPath, `c:\legacy`},
+		{"file:///D:", WindowsPath, `D:\`},
+		{"file:///D:/", WindowsPath, `D:\`},
+		{"file://server/share/dir/file.txt", WindowsPath, `\\server\share\dir\file.txt`},
+		{"file://server/share", WindowsPath, `\\server\share`},
+	} {
+		got, err := FileURLToPath(mustParse(t, tc.in), tc.style)
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.want, got, tc.in)
+	}
+
+	for _, tc := range []struct {
+		in    string
+		style PathStyle
+	}{
+		{"http://example.com/etc/passwd", PosixPath},
+		{"file://server/share/x", PosixPath},
+		{"file://user@localhost/etc", PosixPath},
+		{"file://localhost:8080/etc", PosixPath},
+		{"file:///dir%2Fname", PosixPath},
+		{"file:///dir/nul%00", PosixPath},
+		{"file:///C:/dir%5Cname", WindowsPath},
+		{"file:///etc/passwd", WindowsPath},
+		{"file:///C", WindowsPath},
+		{"file:///CD:/x", WindowsPath},
+		{"file:///C:x", WindowsPath},
+		{"file://server/", WindowsPath},
+		{"file://server", WindowsPath},
+	} {
+		_, err := FileURLToPath(mustParse(t, tc.in), tc.style)
+		assert.True(t, errors.Is(err, errInvalidFileURL), "%s: %v", tc.in, err)
+	}
+}
+
+func TestPathToFileURL(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		style PathStyle
+		want  string
+	}{
+		{"/etc/passwd", PosixPath, "file:///etc/passwd"},
+		{"/", PosixPath, "file:///"},
+		{"//etc", PosixPath, "file:///etc"},
+		{"/home/a b/€#1?.txt", PosixPath, "file:///home/a%20b/%E2%82%AC%231%3F.txt"},
+		{"/100%/[x]", PosixPath, "file:///100%25/%5Bx%5D"},
+		{`/dir/back\slash`, PosixPath, "file:///dir/back%5Cslash"},
+		{"docs/a b.txt", PosixPath, "docs/a%20b.txt"},
+		{"a:b/c", PosixPath, "./a:b/c"},
+		{`C:\Program Files\x.exe`, WindowsPath, "file:///C:/Program%20Files/x.exe"},
+		{`c:/dir/`, WindowsPath, "file:///c:/dir/"},
+		{`D:`, WindowsPath, "file:///D:/"},
+		{`\\server\share\dir\file.txt`, WindowsPath, "file://server/share/dir/file.txt"},
+		{`\\?\C:\very\long`, WindowsPath, "file:///C:/very/long"},
+		{`\\?\UNC\server\share\x`, WindowsPath, "file://server/share/x"},
+		{`\rooted\dir`, WindowsPath, "/rooted/dir"},
+		{`dir\file.txt`, WindowsPath, "dir/file.txt"},
+		{`C:relative`, WindowsPath, "./C:relative"},
+	} {
+		u := PathToFileURL(tc.in, tc.style)
+		assert.Equal(t, tc.want, u.String(), tc.in)
+		parsed, err := ParseURL(u.String())
+		if assert.NoError(t, err, tc.in) && u.Protocol != "" {
+			assert.Equal(t, *u, *parsed, tc.in)
+		}
+	}
+
+	t.Run("Round trip", func(t *testing.T) {
+		for _, tc := range []struct {
+			path  string
+			style PathStyle
+		}{
+			{"/home/a b/€ 100%.txt", PosixPath},
+			{"/", PosixPath},
+			{`C:\Users\a b\Documents\€.txt`, WindowsPath},
+			{`\\server\share\a b\c`, WindowsPath},
+		} {
+			got, err := FileURLToPath(PathToFileURL(tc.path, tc.style), tc.style)
+			require.NoError(t, err, tc.path)
+			assert.Equal(t, tc.path, got)
+		}
+	})
+
+	t.Run("Relative", func(t *testing.T) {
+		base := PathToFileURL("/srv/www/", PosixPath)
+		u, err := base.Resolve(PathToFileURL("../data/a b.txt", PosixPath).String())
+		require.NoError(t, err)
+		assert.Equal(t, "file:///srv/data/a%20b.txt", u.String())
+	})
+}
@@ -0,0 +1,224 @@
+package fasturl
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// FingerprintOptions configures `Fingerprint` and `Fingerprint128`
+type FingerprintOptions struct {
+	// IgnoreFragment leaves the fragment out, so "/a#x" and "/a#y" share a fingerprint
+	IgnoreFragment bool
+	// IgnoreQueryOrder hashes the query parameters as a multiset, so "a=1&b=2" and "b=2&a=1" share a fingerprint
+	IgnoreQueryOrder bool
+}
+
+// Fingerprint returns the 64-bit FNV-1a hash of the serialization of `Normalize(u)`, without building it.
+// With IgnoreQueryOrder the query is replaced in the serialization by the 8 little-endian bytes of the sum
+// of the hashes of its normalized parameters. The value is part of the API and stays the same across releases and platforms.
+func Fingerprint(u *URL, opts FingerprintOptions) uint64 {
+	h := fnvHash{h64: fnv64Offset}
+	h.writeURL(u, opts)
+	return h.h64
+}
+
+// Fingerprint128 is the 128-bit FNV-1a variant of `Fingerprint` for sets large enough for 64-bit collisions to matter
+func Fingerprint128(u *URL, opts FingerprintOptions) (hi, lo uint64) {
+	h := fnvHash{wide: true, hi: fnv128OffsetHi, lo: fnv128OffsetLo}
+	h.writeURL(u, opts)
+	return h.hi, h.lo
+}
+
+const (
+	fnv64Offset    = 14695981039346656037
+	fnv64Prime     = 1099511628211
+	fnv128OffsetHi = 0x6c62272e07bb0142
+	fnv128OffsetLo = 0x62b821756295c58d
+	// the 128-bit prime is 2^88 + 0x13b
+	fnv128PrimeLo    = 0x13b
+	fnv128PrimeShift = 88 - 64
+)
+
+// fnvHash is a 64 or 128-bit FNV-1a hash fed one byte at a time
+type fnvHash struct {
+	wide   bool
+	h64    uint64
+	hi, lo uint64
+}
+
+func (h *fnvHash) writeByte(c byte) {
+	if !h.wide {
+		h.h64 = (h.h64 ^ uint64(c)) * fnv64Prime
+		return
+	}
+	h.lo ^= uint64(c)
+	carry, lo := bits.Mul64(h.lo, fnv128PrimeLo)
+	h.hi = h.hi*fnv128PrimeLo + h.lo<<fnv128PrimeShift + carry
+	h.lo = lo
+}
+
+func (h *fnvHash) writeString(s string) {
+	for i := 0; i < len(s); i++ {
+		h.writeByte(s[i])
+	}
+}
+
+// writeLower writes s lower cased
+func (h *fnvHash) writeLower(s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		h.writeByte(c)
+	}
+}
+
+// writeNormalized writes s the way `normalizePercentEncoding` rewrites it, lower casing it outside of the remaining
+// percent-encodings if asked to
+func (h *fnvHash) writeNormalized(s string, lower bool) {
+	const upperHex = "0123456789ABCDEF"
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			if lower && 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			h.writeByte(c)
+			continue
+		}
+		d := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(d) {
+			if lower && 'A' <= d && d <= 'Z' {
+				d += 'a' - 'A'
+			}
+			h.writeByte(d)
+		} else {
+			h.writeByte('%')
+			h.writeByte(upperHex[d>>4])
+			h.writeByte(upperHex[d&15])
+		}
+		i += 2
+	}
+}
+
+// writeSum writes the 8 little-endian bytes of a 64-bit sum, or the 16 of a 128-bit one, high half first
+func (h *fnvHash) writeSum(hi, lo uint64) {
+	if h.wide {
+		for i := uint(0); i < 64; i += 8 {
+			h.writeByte(byte(hi >> i))
+		}
+	}
+	for i := uint(0); i < 64; i += 8 {
+		h.writeByte(byte(lo >> i))
+	}
+}
+
+func (h *fnvHash) writeURL(u *URL, opts FingerprintOptions) {
+	if u.Protocol != "" {
+		h.writeLower(u.Protocol)
+		h.writeByte(':')
+	}
+	if !u.opaque && (u.Protocol != "" || u.User != "" || u.Host != "" || u.Port != "") {
+		h.writeString("//")
+	}
+	if u.User != "" {
+		h.writeNormalized(u.User, false)
+		h.writeByte('@')
+	}
+	port := u.Port
+	if port != "" && defaultPortFold(u.Protocol) == port {
+		port = ""
+	}
+	if u.Host != "" || port != "" {
+		h.writeNormalized(u.Host, true)
+		if port != "" {
+			h.writeByte(':')
+			h.writeString(port)
+		}
+	}
+
+	switch {
+	case hasDotSegment(u.Path):
+		// dot segments are rare enough to be worth the allocation
+		h.writeString(removeDotSegments(normalizePercentEncoding(u.Path)))
+	case u.Path == "" && u.Host != "":
+		h.writeByte('/')
+	default:
+		h.writeNormalized(u.Path, false)
+	}
+
+	if u.Query != "" {
+		h.writeByte('?')
+		if opts.IgnoreQueryOrder {
+			h.writeQuerySum(u.Query)
+		} else {
+			h.writeNormalized(u.Query, false)
+		}
+	}
+	if u.Fragment != "" && !opts.IgnoreFragment {
+		h.writeByte('#')
+		h.writeNormalized(u.Fragment, false)
+	}
+}
+
+// writeQuerySum writes the sum of the hashes of every "&" separated parameter of q, which doesn't depend on their order
+func (h *fnvHash) writeQuerySum(q string) {
+	var sumHi, sumLo uint64
+	for q != "" {
+		param := q
+		if i := strings.IndexByte(q, '&'); i >= 0 {
+			param, q = q[:i], q[i+1:]
+		} else {
+			q = ""
+		}
+		p := fnvHash{wide: h.wide, h64: fnv64Offset, hi: fnv128OffsetHi, lo: fnv128OffsetLo}
+		p.writeNormalized(param, false)
+		if h.wide {
+			var carry uint64
+			sumLo, carry = bits.Add64(sumLo, p.lo, 0)
+			sumHi, _ = bits.Add64(sumHi, p.hi, carry)
+		} else {
+			sumLo += p.h64
+		}
+	}
+	h.writeSum(sumHi, sumLo)
+}
+
+// defaultPortFold looks scheme up in `defaultPorts` ignoring case, without lower casing it into a new string
+func defaultPortFold(scheme string) string {
+	for s, port := range defaultPorts {
+		if strings.EqualFold(s, scheme) {
+			return port
+		}
+	}
+	return ""
+}
+
+// hasDotSegment reports whether path has a "." or ".." segment, possibly percent-encoded
+func hasDotSegment(path string) bool {
+	for path != "" {
+		seg := path
+		if i := strings.IndexByte(path, '/'); i >= 0 {
+			seg, path = path[:i], path[i+1:]
+		} else {
+			path = ""
+		}
+		dots := 0
+		for i := 0; i < len(seg) && dots >= 0; i++ {
+			switch {
+			case seg[i] == '.':
+				dots++
+			case seg[i] == '%' && i+2 < len(seg) && seg[i+1] == '2' && (seg[i+2] == 'e' || seg[i+2] == 'E'):
+				dots++
+				i += 2
+			default:
+				dots = -1
+			}
+		}
+		if dots == 1 || dots == 2 {
+			return true
+		}
+	}
+	return false
+}
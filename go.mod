@@ -5,4 +5,5 @@ go 1.14
 require (
 	github.com/dvyukov/go-fuzz v0.0.0-20200318091601-be3528f3a813 // indirect
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/net v0.0.0-20210501142056-aec3718b3fa0
 )
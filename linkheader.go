@@ -0,0 +1,307 @@
+package fasturl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Link is a single link of an RFC 8288 Link header
+type Link struct {
+	// Target is the link target resolved against the context URL
+	Target *URL
+	// Rel holds the lower cased relation types of the rel parameter
+	Rel []string
+	// Anchor is the anchor parameter resolved against the context URL, nil when the link has none
+	Anchor *URL
+	// Params are the target attributes, every parameter but rel and anchor, in order
+	Params []LinkParam
+}
+
+// LinkParam is a target attribute of a `Link`
+type LinkParam struct {
+	// Name is lower cased and ends with "*" for an RFC 8187 extended value, as in "title*"
+	Name string
+	// Value is decoded from its token, quoted-string or extended value form
+	Value string
+	// Language is the language tag of an extended value
+	Language string
+}
+
+var errInvalidLinkHeader = errors.New("fasturl: invalid Link header")
+
+func linkHeaderError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{errInvalidLinkHeader}, args...)...)
+}
+
+// ParseLinkHeader parses a Link header without knowing the URL of the response, relative targets stay relative
+func ParseLinkHeader(header string) ([]Link, error) {
+	return ParseLinkHeaderFrom(nil, header)
+}
+
+// ParseLinkHeaderFrom parses a Link header of the response to a request for context, against which targets and anchors
+// are resolved. Only the first rel and anchor parameters of a link count, as RFC 8288 appendix B.3 requires.
+func ParseLinkHeaderFrom(context *URL, header string) ([]Link, error) {
+	if context == nil {
+		context = &URL{}
+	}
+
+	var links []Link
+	s := header
+	for {
+		s = trimOWS(s)
+		if s == "" {
+			return links, nil
+		}
+		if s[0] == ',' {
+			// empty list elements are allowed
+			s = s[1:]
+			continue
+		}
+		if s[0] != '<' {
+			return nil, linkHeaderError("expected '<' at %q", s)
+		}
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			return nil, linkHeaderError("unterminated target")
+		}
+		target, err := context.Resolve(strings.TrimSpace(s[1:end]))
+		if err != nil {
+			return nil, linkHeaderError("target %q doesn't parse", s[1:end])
+		}
+		link := Link{Target: target}
+		s = s[end+1:]
+
+		seenRel := false
+		for {
+			s = trimOWS(s)
+			if s == "" || s[0] == ',' {
+				break
+			}
+			if s[0] != ';' {
+				return nil, linkHeaderError("expected ';' at %q", s)
+			}
+			var p LinkParam
+			if p, s, err = parseLinkParam(trimOWS(s[1:])); err != nil {
+				return nil, err
+			}
+
+			switch p.Name {
+			case "rel":
+				if !seenRel {
+					seenRel = true
+					link.Rel = strings.Fields(strings.ToLower(p.Value))
+				}
+			case "anchor":
+				if link.Anchor == nil {
+					if link.Anchor, err = context.Resolve(p.Value); err != nil {
+						return nil, linkHeaderError("anchor %q doesn't parse", p.Value)
+					}
+				}
+			default:
+				link.Params = append(link.Params, p)
+			}
+		}
+		links = append(links, link)
+	}
+}
+
+// parseLinkParam parses a single "name=value" parameter and returns the rest of s
+func parseLinkParam(s string) (LinkParam, string, error) {
+	i := 0
+	for i < len(s) && isTokenChar(s[i]) {
+		i++
+	}
+	if i == 0 {
+		return LinkParam{}, s, linkHeaderError("expected a parameter name at %q", s)
+	}
+	p := LinkParam{Name: strings.ToLower(s[:i])}
+	s = trimOWS(s[i:])
+	if s == "" || s[0] != '=' {
+		// a parameter without a value, such as "; crossorigin"
+		return p, s, nil
+	}
+	s = trimOWS(s[1:])
+
+	if s != "" && s[0] == '"' {
+		var sb strings.Builder
+		for i = 1; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			sb.WriteByte(s[i])
+		}
+		if i == len(s) {
+			return p, s, linkHeaderError("unterminated quoted string")
+		}
+		p.Value, s = sb.String(), s[i+1:]
+	} else {
+		i = 0
+		for i < len(s) && isTokenChar(s[i]) {
+			i++
+		}
+		p.Value, s = s[:i], s[i:]
+	}
+
+	if strings.HasSuffix(p.Name, "*") {
+		value, lang, ok := decodeExtValue(p.Value)
+		if !ok {
+			return p, s, linkHeaderError("invalid extended value %q", p.Value)
+		}
+		p.Value, p.Language = value, lang
+	}
+	return p, s, nil
+}
+
+// decodeExtValue decodes an RFC 8187 ext-value such as "UTF-8'en'%E2%82%AC%20rates"
+func decodeExtValue(s string) (value, lang string, ok bool) {
+	parts := strings.SplitN(s, "'", 3)
+	if len(parts) != 3 {
+		return "", "", false
+	}
+	raw := make([]byte, 0, len(parts[2]))
+	for i := 0; i < len(parts[2]); i++ {
+		c := parts[2][i]
+		if c == '%' {
+			if i+2 >= len(parts[2]) || !isHex(parts[2][i+1]) || !isHex(parts[2][i+2]) {
+				return "", "", false
+			}
+			c = unhex(parts[2][i+1])<<4 | unhex(parts[2][i+2])
+			i += 2
+		} else if !isAttrChar(c) {
+			return "", "", false
+		}
+		raw = append(raw, c)
+	}
+
+	switch strings.ToUpper(parts[0]) {
+	case "UTF-8":
+		if !utf8.Valid(raw) {
+			return "", "", false
+		}
+		return string(raw), parts[1], true
+	case "ISO-8859-1":
+		runes := make([]rune, len(raw))
+		for i, c := range raw {
+			runes[i] = rune(c)
+		}
+		return string(runes), parts[1], true
+	}
+	return "", "", false
+}
+
+// HasRel reports whether rel is one of the relation types of the link, ignoring case
+func (l Link) HasRel(rel string) bool {
+	for _, r := range l.Rel {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Param returns the value of the first target attribute named name
+func (l Link) Param(name string) (string, bool) {
+	for _, p := range l.Params {
+		if strings.EqualFold(p.Name, name) {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// Title returns the title of the link, preferring "title*" over "title" as RFC 8288 recommends
+func (l Link) Title() string {
+	if t, ok := l.Param("title*"); ok {
+		return t
+	}
+	t, _ := l.Param("title")
+	return t
+}
+
+// String serializes the link as a link-value of a Link header
+func (l Link) String() string {
+	var sb strings.Builder
+	sb.WriteByte('<')
+	if l.Target != nil {
+		sb.WriteString(l.Target.String())
+	}
+	sb.WriteByte('>')
+	if len(l.Rel) > 0 {
+		sb.WriteString("; rel=")
+		writeLinkParamValue(&sb, strings.Join(l.Rel, " "))
+	}
+	if l.Anchor != nil {
+		sb.WriteString("; anchor=")
+		writeLinkParamValue(&sb, l.Anchor.String())
+	}
+	for _, p := range l.Params {
+		sb.WriteString("; ")
+		sb.WriteString(p.Name)
+		if strings.HasSuffix(p.Name, "*") {
+			sb.WriteString("=UTF-8'")
+			sb.WriteString(p.Language)
+			sb.WriteByte('\'')
+			const upperHex = "0123456789ABCDEF"
+			for i := 0; i < len(p.Value); i++ {
+				if c := p.Value[i]; isAttrChar(c) {
+					sb.WriteByte(c)
+				} else {
+					sb.WriteByte('%')
+					sb.WriteByte(upperHex[c>>4])
+					sb.WriteByte(upperHex[c&15])
+				}
+			}
+			continue
+		}
+		if p.Value != "" {
+			sb.WriteByte('=')
+			writeLinkParamValue(&sb, p.Value)
+		}
+	}
+	return sb.String()
+}
+
+// FormatLinkHeader serializes links as the value of a Link header
+func FormatLinkHeader(links []Link) string {
+	values := make([]string, len(links))
+	for i, l := range links {
+		values[i] = l.String()
+	}
+	return strings.Join(values, ", ")
+}
+
+// writeLinkParamValue writes v as a token when it is one and as a quoted-string otherwise
+func writeLinkParamValue(sb *strings.Builder, v string) {
+	token := v != ""
+	for i := 0; i < len(v) && token; i++ {
+		token = isTokenChar(v[i])
+	}
+	if token {
+		sb.WriteString(v)
+		return
+	}
+	sb.WriteByte('"')
+	for i := 0; i < len(v); i++ {
+		if v[i] == '"' || v[i] == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(v[i])
+	}
+	sb.WriteByte('"')
+}
+
+func trimOWS(s string) string {
+	return strings.TrimLeft(s, " \t")
+}
+
+// isTokenChar reports whether c is a tchar of RFC 9110
+func isTokenChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// isAttrChar reports whether c is an attr-char of RFC 8187
+func isAttrChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
@@ -0,0 +1,121 @@
+package fasturl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	t.Run("Pagination", func(t *testing.T) {
+		const header = `<https://api.example.com/repos?page=2>; rel="next", ` +
+			`<https://api.example.com/repos?page=34>; rel="last"`
+		links, err := ParseLinkHeader(header)
+		require.NoError(t, err)
+		require.Len(t, links, 2)
+		assert.Equal(t, "https://api.example.com/repos?page=2", links[0].Target.String())
+		assert.Equal(t, []string{"next"}, links[0].Rel)
+		assert.True(t, links[1].HasRel("LAST"))
+		assert.False(t, links[1].HasRel("next"))
+		assert.Equal(t, "<https://api.example.com/repos?page=2>; rel=next, "+
+			"<https://api.example.com/repos?page=34>; rel=last", FormatLinkHeader(links))
+	})
+
+	t.Run("Cursor pagination", func(t *testing.T) {
+		const header = `<https://api.example.com/items?cursor=a/b?c>; rel="next", <https://api.example.com/items>; rel="first"`
+		links, err := ParseLinkHeader(header)
+		require.NoError(t, err)
+		require.Len(t, links, 2)
+		assert.Equal(t, "cursor=a/b?c", links[0].Target.Query)
+		v, _ := links[0].Target.QueryValue("cursor")
+		assert.Equal(t, "a/b?c", v)
+		assert.Equal(t, "<https://api.example.com/items?cursor=a/b?c>; rel=next, "+
+			"<https://api.example.com/items>; rel=first", FormatLinkHeader(links))
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		context := mustParse(t, "https://example.com/a/b?page=1")
+		links, err := ParseLinkHeaderFrom(context, `</a/c?page=2>; rel="next prev"; anchor="#x", <d> ; REL=Self ; rel=ignored`)
+		require.NoError(t, err)
+		require.Len(t, links, 2)
+		assert.Equal(t, "https://example.com/a/c?page=2", links[0].Target.String())
+		assert.Equal(t, []string{"next", "prev"}, links[0].Rel)
+		assert.Equal(t, "https://example.com/a/b?page=1#x", links[0].Anchor.String())
+		assert.Equal(t, "https://example.com/a/d", links[1].Target.String())
+		assert.Equal(t, []string{"self"}, links[1].Rel)
+		assert.Nil(t, links[1].Anchor)
+
+		links, err = ParseLinkHeader(`<d>; rel=self`)
+		require.NoError(t, err)
+		assert.Equal(t, "d", links[0].Target.String())
+
+		l := Link{Target: &URL{Path: "d"}, Anchor: &URL{Fragment: `a"b\c`}}
+		assert.Equal(t, `<d>; anchor="#a\"b\\c"`, l.String())
+	})
+
+	t.Run("Target attributes", func(t *testing.T) {
+		// the examples of section 3.5 of RFC 8288
+		links, err := ParseLinkHeader(`<http://example.com/TheBook/chapter2>; rel="previous"; title="previous chapter"`)
+		require.NoError(t, err)
+		assert.Equal(t, "previous chapter", links[0].Title())
+
+		links, err = ParseLinkHeader(`</TheBook/chapter2>; rel="previous"; title*=UTF-8'de'letztes%20Kapitel, ` +
+			`</TheBook/chapter4>; rel="next"; title*=UTF-8'de'n%c3%a4chstes%20Kapitel`)
+		require.NoError(t, err)
+		require.Len(t, links, 2)
+		assert.Equal(t, []LinkParam{{Name: "title*", Value: "letztes Kapitel", Language: "de"}}, links[0].Params)
+		assert.Equal(t, "nächstes Kapitel", links[1].Title())
+		assert.Equal(t, `</TheBook/chapter2>; rel=previous; title*=UTF-8'de'letztes%20Kapitel, `+
+			`</TheBook/chapter4>; rel=next; title*=UTF-8'de'n%C3%A4chstes%20Kapitel`, FormatLinkHeader(links))
+
+		links, err = ParseLinkHeader(`<http://example.org/>; rel="start http://example.net/relation/other"`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"start", "http://example.net/relation/other"}, links[0].Rel)
+		assert.Equal(t, `<http://example.org/>; rel="start http://example.net/relation/other"`, links[0].String())
+
+		links, err = ParseLinkHeader(`<https://cdn.example.com/font.woff2>; rel=preload; as=font; crossorigin; ` +
+			`title="say \"hi\""; title*=iso-8859-1'en'%A3%20rates; type="font/woff2"`)
+		require.NoError(t, err)
+		assert.Equal(t, []LinkParam{
+			{Name: "as", Value: "font"},
+			{Name: "crossorigin"},
+			{Name: "title", Value: `say "hi"`},
+			{Name: "title*", Value: "£ rates", Language: "en"},
+			{Name: "type", Value: "font/woff2"},
+		}, links[0].Params)
+		assert.Equal(t, "£ rates", links[0].Title())
+		v, ok := links[0].Param("TYPE")
+		assert.True(t, ok)
+		assert.Equal(t, "font/woff2", v)
+		assert.Equal(t, `<https://cdn.example.com/font.woff2>; rel=preload; as=font; crossorigin; `+
+			`title="say \"hi\""; title*=UTF-8'en'%C2%A3%20rates; type="font/woff2"`, links[0].String())
+	})
+
+	t.Run("Empty elements", func(t *testing.T) {
+		links, err := ParseLinkHeader(` , <a:b>; rel=x,, `)
+		require.NoError(t, err)
+		assert.Len(t, links, 1)
+		links, err = ParseLinkHeader("")
+		require.NoError(t, err)
+		assert.Empty(t, links)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, header := range []string{
+			`https://example.com/; rel=next`,
+			`<https://example.com/; rel=next`,
+			`<https://example.com/> rel=next`,
+			`<https://example.com/>; rel="next`,
+			`<https://example.com/>; =next`,
+			`<https://example.com/>; title*=UTF-8''%ZZ`,
+			`<https://example.com/>; title*=KOI8-R''abc`,
+			`<https://example.com/>; title*=abc`,
+			`<https://example.com/>; title*=UTF-8''%FF`,
+		} {
+			_, err := ParseLinkHeader(header)
+			assert.True(t, errors.Is(err, errInvalidLinkHeader), header)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package fasturl
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalText implements `encoding.TextMarshaler`
+func (u URL) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements `encoding.TextUnmarshaler`, the text is parsed with `ParseURL`
+func (u *URL) UnmarshalText(text []byte) error {
+	return u.Set(string(text))
+}
+
+// MarshalJSON implements `json.Marshaler`, the URL is encoded as a string
+func (u URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+// UnmarshalJSON implements `json.Unmarshaler`, accepting both a string and the object form of `URLObject`
+func (u *URL) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '{' {
+		return (*URLObject)(u).UnmarshalJSON(data)
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return u.Set(s)
+}
+
+// Scan implements `sql.Scanner` for string and []byte columns, NULL scans to the empty URL
+func (u *URL) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		*u = URL{}
+		return nil
+	case string:
+		return u.Set(src)
+	case []byte:
+		return u.Set(string(src))
+	default:
+		return fmt.Errorf("fasturl: cannot scan %T into URL", src)
+	}
+}
+
+// Value implements `driver.Valuer`, the URL is stored as a string and the empty URL as NULL
+func (u URL) Value() (driver.Value, error) {
+	if u == (URL{}) {
+		return nil, nil
+	}
+	return u.String(), nil
+}
+
+// Set implements `flag.Value`, the value is parsed with `ParseURL`
+func (u *URL) Set(s string) error {
+	p, err := ParseURL(s)
+	if err != nil {
+		return err
+	}
+	*u = *p
+	return nil
+}
+
+// URLObject is a URL that is encoded to JSON as an object of its components instead of a string
+type URLObject URL
+
+type urlObject struct {
+	Protocol string `json:"protocol,omitempty"`
+	User     string `json:"user,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Port     string `json:"port,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Query    string `json:"query,omitempty"`
+	Fragment string `json:"fragment,omitempty"`
+	Opaque   bool   `json:"opaque,omitempty"`
+}
+
+// MarshalJSON implements `json.Marshaler`
+func (o URLObject) MarshalJSON() ([]byte, error) {
+	return json.Marshal(urlObject{o.Protocol, o.User, o.Host, o.Port, o.Path, o.Query, o.Fragment, o.opaque})
+}
+
+// UnmarshalJSON implements `json.Unmarshaler`, the components are reassembled and parsed with `ParseURL`
+func (o *URLObject) UnmarshalJSON(data []byte) error {
+	var obj urlObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	u := URL{Protocol: obj.Protocol, User: obj.User, Host: obj.Host, Port: obj.Port, Path: obj.Path, Query: obj.Query, Fragment: obj.Fragment, opaque: obj.Opaque}
+	return (*URL)(o).Set(u.String())
+}
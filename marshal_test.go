@@ -0,0 +1,120 @@
+package fasturl
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"encoding/json"
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ encoding.TextMarshaler   = URL{}
+	_ encoding.TextUnmarshaler = &URL{}
+	_ json.Marshaler           = URL{}
+	_ json.Unmarshaler         = &URL{}
+	_ json.Marshaler           = URLObject{}
+	_ json.Unmarshaler         = &URLObject{}
+	_ sql.Scanner              = &URL{}
+	_ driver.Valuer            = URL{}
+	_ flag.Value               = &URL{}
+)
+
+func TestMarshal(t *testing.T) {
+	type config struct {
+		Endpoint URL
+		Mirror   *URL       `json:",omitempty"`
+		Object   URLObject  `json:",omitempty"`
+		Optional *URLObject `json:",omitempty"`
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		u, err := ParseURL(_url)
+		require.NoError(t, err)
+
+		data, err := json.Marshal(config{Endpoint: *u, Object: URLObject(*u)})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"Endpoint": "https://www.google.com/dir/1/2/search.html?arg=0-a&arg1=1-b&arg3-c#hash",
+			"Object": {"protocol": "https", "host": "www.google.com", "path": "/dir/1/2/search.html", "query": "arg=0-a&arg1=1-b&arg3-c", "fragment": "hash"}
+		}`, string(data))
+
+		var c config
+		require.NoError(t, json.Unmarshal(data, &c))
+		assert.Equal(t, *u, c.Endpoint)
+		assert.Equal(t, URLObject(*u), c.Object)
+	})
+
+	t.Run("JSON object into URL", func(t *testing.T) {
+		var c config
+		require.NoError(t, json.Unmarshal([]byte(`{"Endpoint": {"protocol": "telnet", "host": "192.0.2.16", "port": "80", "path": "/"}}`), &c))
+
+		assert.Equal(t, "telnet://192.0.2.16:80/", c.Endpoint.String())
+	})
+
+	t.Run("JSON opaque", func(t *testing.T) {
+		u, err := ParseURL("mailto:John.Doe@example.com")
+		require.NoError(t, err)
+
+		data, err := json.Marshal(URLObject(*u))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"protocol": "mailto", "user": "John.Doe", "host": "example.com", "opaque": true}`, string(data))
+
+		var o URLObject
+		require.NoError(t, json.Unmarshal(data, &o))
+		assert.Equal(t, URLObject(*u), o)
+		assert.Equal(t, "mailto:John.Doe@example.com", (*URL)(&o).String())
+	})
+
+	t.Run("JSON invalid", func(t *testing.T) {
+		var c config
+		assert.EqualError(t, json.Unmarshal([]byte(`{"Endpoint": "I'm not a url"}`), &c), "Failed to match URL")
+		assert.EqualError(t, json.Unmarshal([]byte(`{"Object": {"host": "not a host"}}`), &c), "Failed to match URL")
+		assert.Error(t, json.Unmarshal([]byte(`{"Endpoint": 42}`), &c))
+	})
+
+	t.Run("Text", func(t *testing.T) {
+		var u URL
+		require.NoError(t, u.UnmarshalText([]byte("ftp://ftp.is.co.za/rfc/rfc1808.txt")))
+		assert.Equal(t, "ftp.is.co.za", u.Host)
+
+		text, err := u.MarshalText()
+		require.NoError(t, err)
+		assert.Equal(t, "ftp://ftp.is.co.za/rfc/rfc1808.txt", string(text))
+
+		assert.Error(t, u.UnmarshalText([]byte("I'm not a url")))
+	})
+
+	t.Run("SQL", func(t *testing.T) {
+		var u URL
+		require.NoError(t, u.Scan([]byte("http://example.com?foo=bar")))
+		assert.Equal(t, "foo=bar", u.Query)
+
+		v, err := u.Value()
+		require.NoError(t, err)
+		assert.Equal(t, "http://example.com?foo=bar", v)
+
+		require.NoError(t, u.Scan(nil))
+		assert.Equal(t, URL{}, u)
+
+		v, err = u.Value()
+		require.NoError(t, err)
+		assert.Nil(t, v, "the empty URL is stored as NULL")
+
+		assert.Error(t, u.Scan("I'm not a url"))
+		assert.Error(t, u.Scan(42))
+	})
+
+	t.Run("Flag", func(t *testing.T) {
+		var u URL
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Var(&u, "endpoint", "")
+
+		require.NoError(t, fs.Parse([]string{"-endpoint", "http://www.ietf.org/rfc/rfc2396.txt"}))
+		assert.Equal(t, "/rfc/rfc2396.txt", u.Path)
+	})
+}
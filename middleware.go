@@ -0,0 +1,66 @@
+package fasturl
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying u
+func NewContext(ctx context.Context, u *URL) context.Context {
+	return context.WithValue(ctx, contextKey{}, u)
+}
+
+// FromContext returns the URL stored by `Middleware` or `NewContext`
+func FromContext(ctx context.Context) (*URL, bool) {
+	u, ok := ctx.Value(contextKey{}).(*URL)
+	return u, ok
+}
+
+// Middleware parses the request target of every request and stores it in the request context for `FromContext`.
+// Requests with a target that isn't valid for their method under RFC 9112 are answered with 400 Bad Request.
+type Middleware struct {
+	// Normalize stores the result of `Normalize` instead of the target as it was sent
+	Normalize bool
+	// Redirect answers requests whose path isn't normalized with a redirect to the normalized one,
+	// 301 Moved Permanently for GET and HEAD and 308 Permanent Redirect for other methods
+	Redirect bool
+}
+
+// Handler wraps next with the middleware
+func (m Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := r.RequestURI
+		if target == "" {
+			target = r.URL.RequestURI()
+		}
+		t, err := ParseRequestTarget(target)
+		if err != nil || !t.AllowedFor(r.Method) {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+
+		u := t.URL
+		if (m.Normalize || m.Redirect) && (t.Form == OriginForm || t.Form == AbsoluteForm) {
+			n := Normalize(u)
+			if m.Redirect && n.Path != u.Path {
+				code := http.StatusPermanentRedirect
+				if r.Method == http.MethodGet || r.Method == http.MethodHead {
+					code = http.StatusMovedPermanently
+				}
+				if n.Host == "" {
+					// browsers follow a path starting with "//" or "/\" as a scheme-relative URL to another host
+					n.Path = "/" + strings.TrimLeft(n.Path, `/\`)
+				}
+				http.Redirect(w, r, n.String(), code)
+				return
+			}
+			if m.Normalize {
+				u = n
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), u)))
+	})
+}
@@ -0,0 +1,98 @@
+package fasturl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveMiddleware(m Middleware, method, target string) (*httptest.ResponseRecorder, *URL) {
+	var got *URL
+	h := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Method, req.RequestURI = method, target
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w, got
+}
+
+func TestMiddleware(t *testing.T) {
+	for _, tc := range []struct {
+		method, target string
+		want           URL
+	}{
+		{"GET", "/", URL{Path: "/"}},
+		{"GET", "/search?q=a/b?c", URL{Path: "/search", Query: "q=a/b?c"}},
+		{"GET", "//double/slash", URL{Path: "//double/slash"}},
+		{"GET", "http://example.com:8080/a?b", URL{Protocol: "http", Host: "example.com", Port: "8080", Path: "/a", Query: "b"}},
+		{"GET", "http://x/?u=/y", URL{Protocol: "http", Host: "x", Path: "/", Query: "u=/y"}},
+		{"CONNECT", "example.com:443", URL{Host: "example.com", Port: "443"}},
+		{"CONNECT", "[::1]:8443", URL{Host: "[::1]", Port: "8443"}},
+		{"OPTIONS", "*", URL{Path: "*"}},
+	} {
+		w, got := serveMiddleware(Middleware{}, tc.method, tc.target)
+		require.Equal(t, http.StatusOK, w.Code, tc.target)
+		require.NotNil(t, got, tc.target)
+		assert.Equal(t, tc.want, *got, tc.target)
+	}
+
+	t.Run("Bad request", func(t *testing.T) {
+		for _, tc := range []struct{ method, target string }{
+			{"GET", "*"},
+			{"GET", "example.com:443"},
+			{"GET", "/a#frag"},
+			{"GET", "/a b"},
+			{"GET", "/caf\xc3\xa9"},
+			{"GET", "mailto:a@example.com"},
+			{"GET", "http:///path"},
+			{"CONNECT", "example.com"},
+			{"CONNECT", "/path"},
+			{"CONNECT", "http://example.com:443"},
+			{"CONNECT", "user@example.com:443"},
+			{"CONNECT", "example.com:443/path"},
+		} {
+			w, got := serveMiddleware(Middleware{}, tc.method, tc.target)
+			assert.Equal(t, http.StatusBadRequest, w.Code, tc.target)
+			assert.Nil(t, got, tc.target)
+		}
+	})
+
+	t.Run("Normalize", func(t *testing.T) {
+		w, got := serveMiddleware(Middleware{Normalize: true}, "GET", "HTTP://Example.COM:80/a/./b/../%7ec")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, URL{Protocol: "http", Host: "example.com", Path: "/a/~c"}, *got)
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		w, got := serveMiddleware(Middleware{Redirect: true}, "GET", "/a/../b/%7euser?x=1")
+		assert.Nil(t, got)
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/b/~user?x=1", w.Header().Get("Location"))
+
+		w, _ = serveMiddleware(Middleware{Redirect: true}, "POST", "/./form")
+		assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+		assert.Equal(t, "/form", w.Header().Get("Location"))
+
+		w, got = serveMiddleware(Middleware{Redirect: true}, "GET", "/already/canonical?x=%7e")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "x=%7e", got.Query)
+	})
+
+	t.Run("Redirect stays on the host", func(t *testing.T) {
+		for target, want := range map[string]string{
+			"//evil.com/x/..": "/evil.com/",
+			`/\evil.com/x/..`: "/evil.com/",
+			"///evil.com/./x": "/evil.com/x",
+			"/\\/evil.com/..": "/",
+		} {
+			w, _ := serveMiddleware(Middleware{Redirect: true}, "GET", target)
+			assert.Equal(t, http.StatusMovedPermanently, w.Code, target)
+			assert.Equal(t, want, w.Header().Get("Location"), target)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package fasturl
+
+import "strings"
+
+// defaultPorts maps lower case schemes to the port implied when none is given
+var defaultPorts = map[string]string{
+	"ftp":   "21",
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// Normalize returns a copy of u with the syntax and scheme based normalizations of RFC 3986 section 6.2 applied:
+// the scheme and host are lower cased, percent-encodings are upper cased and decoded where they encode an unreserved character,
+// dot segments are removed from the path, a default port is dropped and an empty path is replaced by "/" when there is a host
+func Normalize(u *URL) *URL {
+	n := *u
+	n.Protocol = strings.ToLower(n.Protocol)
+	n.Host = lowerOutsideEscapes(normalizePercentEncoding(n.Host))
+	n.Path = removeDotSegments(normalizePercentEncoding(n.Path))
+	n.Query = normalizePercentEncoding(n.Query)
+	n.Fragment = normalizePercentEncoding(n.Fragment)
+	if n.Port != "" && defaultPorts[n.Protocol] == n.Port {
+		n.Port = ""
+	}
+	if n.Path == "" && n.Host != "" {
+		n.Path = "/"
+	}
+	return &n
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// normalizePercentEncoding upper cases the hex digits of every percent-encoding in s and decodes those of unreserved characters
+func normalizePercentEncoding(s string) string {
+	return rewritePercentEncoding(s, true)
+}
+
+// rewritePercentEncoding upper cases the hex digits of every percent-encoding in s, decoding those of unreserved characters if asked to
+func rewritePercentEncoding(s string, decodeUnreserved bool) string {
+	i := strings.IndexByte(s, '%')
+	if i < 0 {
+		return s
+	}
+
+	const upperHex = "0123456789ABCDEF"
+	b := make([]byte, 0, len(s))
+	b = append(b, s[:i]...)
+	for ; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b = append(b, s[i])
+			continue
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if decodeUnreserved && isUnreserved(c) {
+			b = append(b, c)
+		} else {
+			b = append(b, '%', upperHex[c>>4], upperHex[c&15])
+		}
+		i += 2
+	}
+	return string(b)
+}
+
+// lowerOutsideEscapes lower cases the letters of s but not the hex digits of its percent-encodings,
+// which are run after decoding so that an encoded letter is lower cased too
+func lowerOutsideEscapes(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			if b != nil {
+				b = append(b, s[i:i+3]...)
+			}
+			i += 2
+			continue
+		}
+		if 'A' <= c && c <= 'Z' {
+			if b == nil {
+				b = append(make([]byte, 0, len(s)), s[:i]...)
+			}
+			c += 'a' - 'A'
+		}
+		if b != nil {
+			b = append(b, c)
+		}
+	}
+	if b == nil {
+		return s
+	}
+	return string(b)
+}
+
+// removeDotSegments implements the algorithm of RFC 3986 section 5.2.4
+func removeDotSegments(path string) string {
+	if !strings.Contains(path, ".") {
+		return path
+	}
+
+	out := make([]string, 0, strings.Count(path, "/")+1)
+	in := path
+	for in != "" {
+		switch {
+		case strings.HasPrefix(in, "../"):
+			in = in[3:]
+		case strings.HasPrefix(in, "./"):
+			in = in[2:]
+		case strings.HasPrefix(in, "/./"):
+			in = in[2:]
+		case in == "/.":
+			in = "/"
+		case strings.HasPrefix(in, "/../"):
+			in = in[3:]
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		case in == "/..":
+			in = "/"
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+		case in == "." || in == "..":
+			in = ""
+		default:
+			j := strings.IndexByte(in[1:], '/') + 1
+			if j <= 0 {
+				j = len(in)
+			}
+			out = append(out, in[:j])
+			in = in[j:]
+		}
+	}
+	return strings.Join(out, "")
+}
@@ -0,0 +1,93 @@
+package fasturl
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Origin is the RFC 6454 origin of a URL, an Origin without a scheme is opaque
+type Origin struct {
+	Scheme string
+	Host   string
+	// Port is empty when it is the default of the scheme
+	Port string
+}
+
+// tupleOriginSchemes are the schemes whose URLs have a tuple origin, every other scheme gets an opaque one
+var tupleOriginSchemes = map[string]bool{
+	"ftp":   true,
+	"http":  true,
+	"https": true,
+	"ws":    true,
+	"wss":   true,
+}
+
+// Origin returns the origin of u, scheme and host are lower cased and the port is dropped when it is the scheme's default.
+// URLs of schemes without a network origin such as "file:" or "data:", or without a host, have an opaque origin.
+func (u *URL) Origin() Origin {
+	scheme := strings.ToLower(u.Protocol)
+	if !tupleOriginSchemes[scheme] || u.opaque || u.Host == "" {
+		return Origin{}
+	}
+
+	port := u.Port
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return Origin{}
+		}
+		port = strconv.Itoa(n)
+		if port == defaultPorts[scheme] {
+			port = ""
+		}
+	}
+	return Origin{Scheme: scheme, Host: strings.ToLower(u.Host), Port: port}
+}
+
+// Opaque reports whether o is an opaque origin
+func (o Origin) Opaque() bool {
+	return o.Scheme == ""
+}
+
+// String returns the ASCII serialization of o, "null" when it is opaque
+func (o Origin) String() string {
+	if o.Opaque() {
+		return "null"
+	}
+	if o.Port == "" {
+		return o.Scheme + "://" + o.Host
+	}
+	return o.Scheme + "://" + o.Host + ":" + o.Port
+}
+
+// Site returns the registrable domain of o's host, or the host itself when it is an IP address or has no registrable domain
+func (o Origin) Site() string {
+	if o.Opaque() {
+		return ""
+	}
+	if _, ip := ClassifyHost(o.Host); ip != nil {
+		return o.Host
+	}
+	site, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimSuffix(o.Host, "."))
+	if err != nil {
+		return o.Host
+	}
+	return site
+}
+
+// SameOrigin reports whether a and b have the same tuple origin, opaque origins are never the same
+func SameOrigin(a, b *URL) bool {
+	oa := a.Origin()
+	return !oa.Opaque() && oa == b.Origin()
+}
+
+// SameSite reports whether a and b are schemefully same-site: same scheme and same registrable domain
+func SameSite(a, b *URL) bool {
+	oa, ob := a.Origin(), b.Origin()
+	if oa.Opaque() || ob.Opaque() || oa.Scheme != ob.Scheme {
+		return false
+	}
+	return oa.Site() == ob.Site()
+}
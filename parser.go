@@ -17,27 +17,38 @@ const url_parser_en_main int = 45
 // URL represents the different parts of a parsed URL
 type URL struct {
 	Protocol string
+	User     string
 	Host     string
 	Port     string
 	Path     string
 	Query    string
 	Fragment string
+
+	// opaque is set when the scheme is not followed by "//", as in "mailto:" or "data:"
+	opaque bool
 }
 
 // ParseURL parses a given URL and returns a `URL` representing the different parts
 func ParseURL(data string) (*URL, error) {
-	mark, host_mark, port_mark, cs, p, pe, eof := 0, 0, 0, url_parser_en_main, 0, len(data), len(data)
-
 	u := &URL{}
+	if err := parseURL(data, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// parseURL parses data into the zero value u, it doesn't allocate unless data fails to parse
+func parseURL(data string, u *URL) error {
+	mark, host_mark, port_mark, cs, p, pe, eof := 0, 0, 0, url_parser_en_main, 0, len(data), len(data)
 
-//line parser.go:39
+//line parser.go:46
 	{
 		cs = url_parser_start
 	}
 
-//line parser.rl:115
+//line parser.rl:126
 
-//line parser.go:46
+//line parser.go:53
 	{
 		if p == pe {
 			goto _test_eof
@@ -424,7 +435,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof46
 		}
 	st_case_46:
-//line parser.go:433
+//line parser.go:440
 		switch data[p] {
 		case 33:
 			goto st46
@@ -539,7 +550,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof47
 		}
 	st_case_47:
-//line parser.go:548
+//line parser.go:555
 		if 32 <= data[p] && data[p] <= 126 {
 			goto tr54
 		}
@@ -553,7 +564,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof48
 		}
 	st_case_48:
-//line parser.go:562
+//line parser.go:569
 		if 32 <= data[p] && data[p] <= 126 {
 			goto st48
 		}
@@ -569,7 +580,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof1
 		}
 	st_case_1:
-//line parser.go:578
+//line parser.go:585
 		switch {
 		case data[p] < 65:
 			if 48 <= data[p] && data[p] <= 57 {
@@ -642,7 +653,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof49
 		}
 	st_case_49:
-//line parser.go:651
+//line parser.go:658
 		switch data[p] {
 		case 35:
 			goto tr57
@@ -673,7 +684,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof50
 		}
 	st_case_50:
-//line parser.go:682
+//line parser.go:689
 		switch data[p] {
 		case 35:
 			goto tr57
@@ -743,7 +754,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof51
 		}
 	st_case_51:
-//line parser.go:752
+//line parser.go:759
 		if data[p] == 35 {
 			goto tr60
 		}
@@ -769,7 +780,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof52
 		}
 	st_case_52:
-//line parser.go:778
+//line parser.go:785
 		if data[p] == 35 {
 			goto tr62
 		}
@@ -797,7 +808,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof3
 		}
 	st_case_3:
-//line parser.go:806
+//line parser.go:813
 		switch data[p] {
 		case 33:
 			goto tr4
@@ -876,7 +887,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof53
 		}
 	st_case_53:
-//line parser.go:885
+//line parser.go:892
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -927,7 +938,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof54
 		}
 	st_case_54:
-//line parser.go:936
+//line parser.go:943
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -978,7 +989,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof55
 		}
 	st_case_55:
-//line parser.go:987
+//line parser.go:994
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -1043,7 +1054,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof56
 		}
 	st_case_56:
-//line parser.go:1052
+//line parser.go:1059
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -1140,7 +1151,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof4
 		}
 	st_case_4:
-//line parser.go:1149
+//line parser.go:1156
 		if data[p] == 58 {
 			goto st5
 		}
@@ -1228,7 +1239,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof7
 		}
 	st_case_7:
-//line parser.go:1237
+//line parser.go:1244
 		if data[p] == 58 {
 			goto st5
 		}
@@ -1274,7 +1285,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof59
 		}
 	st_case_59:
-//line parser.go:1283
+//line parser.go:1290
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -1325,7 +1336,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof60
 		}
 	st_case_60:
-//line parser.go:1334
+//line parser.go:1341
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -1470,7 +1481,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof63
 		}
 	st_case_63:
-//line parser.go:1479
+//line parser.go:1486
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -1535,7 +1546,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof64
 		}
 	st_case_64:
-//line parser.go:1544
+//line parser.go:1551
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -1586,7 +1597,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof65
 		}
 	st_case_65:
-//line parser.go:1595
+//line parser.go:1602
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -1691,7 +1702,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof67
 		}
 	st_case_67:
-//line parser.go:1700
+//line parser.go:1707
 		switch data[p] {
 		case 35:
 			goto tr57
@@ -1802,7 +1813,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof69
 		}
 	st_case_69:
-//line parser.go:1811
+//line parser.go:1818
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -1885,7 +1896,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof70
 		}
 	st_case_70:
-//line parser.go:1894
+//line parser.go:1901
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -1936,7 +1947,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof71
 		}
 	st_case_71:
-//line parser.go:1945
+//line parser.go:1952
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -2045,7 +2056,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof73
 		}
 	st_case_73:
-//line parser.go:2054
+//line parser.go:2061
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -2170,7 +2181,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof75
 		}
 	st_case_75:
-//line parser.go:2179
+//line parser.go:2186
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -2253,7 +2264,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof76
 		}
 	st_case_76:
-//line parser.go:2262
+//line parser.go:2269
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -2304,7 +2315,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof77
 		}
 	st_case_77:
-//line parser.go:2313
+//line parser.go:2320
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -2413,7 +2424,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof79
 		}
 	st_case_79:
-//line parser.go:2422
+//line parser.go:2429
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -2506,7 +2517,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof80
 		}
 	st_case_80:
-//line parser.go:2515
+//line parser.go:2522
 		switch data[p] {
 		case 33:
 			goto st81
@@ -2573,7 +2584,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof81
 		}
 	st_case_81:
-//line parser.go:2582
+//line parser.go:2589
 		switch data[p] {
 		case 33:
 			goto st82
@@ -2640,7 +2651,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof82
 		}
 	st_case_82:
-//line parser.go:2649
+//line parser.go:2656
 		switch data[p] {
 		case 33:
 			goto st83
@@ -2707,7 +2718,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof83
 		}
 	st_case_83:
-//line parser.go:2716
+//line parser.go:2723
 		switch data[p] {
 		case 33:
 			goto st84
@@ -2924,7 +2935,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof12
 		}
 	st_case_12:
-//line parser.go:2933
+//line parser.go:2940
 		if data[p] == 118 {
 			goto st28
 		}
@@ -3112,7 +3123,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof23
 		}
 	st_case_23:
-//line parser.go:3121
+//line parser.go:3128
 		if data[p] == 37 {
 			goto tr31
 		}
@@ -3143,7 +3154,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof86
 		}
 	st_case_86:
-//line parser.go:3152
+//line parser.go:3159
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -3212,7 +3223,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof87
 		}
 	st_case_87:
-//line parser.go:3221
+//line parser.go:3228
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -3434,7 +3445,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof32
 		}
 	st_case_32:
-//line parser.go:3443
+//line parser.go:3450
 		switch data[p] {
 		case 33:
 			goto st8
@@ -3576,7 +3587,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof35
 		}
 	st_case_35:
-//line parser.go:3585
+//line parser.go:3592
 		if data[p] == 58 {
 			goto st5
 		}
@@ -3686,7 +3697,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof90
 		}
 	st_case_90:
-//line parser.go:3695
+//line parser.go:3702
 		switch data[p] {
 		case 33:
 			goto st46
@@ -3735,7 +3746,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof36
 		}
 	st_case_36:
-//line parser.go:3744
+//line parser.go:3751
 		switch data[p] {
 		case 33:
 			goto tr4
@@ -3792,7 +3803,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof91
 		}
 	st_case_91:
-//line parser.go:3801
+//line parser.go:3808
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -3861,7 +3872,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof92
 		}
 	st_case_92:
-//line parser.go:3870
+//line parser.go:3877
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -3932,7 +3943,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof93
 		}
 	st_case_93:
-//line parser.go:3941
+//line parser.go:3948
 		switch data[p] {
 		case 33:
 			goto tr138
@@ -4037,7 +4048,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof94
 		}
 	st_case_94:
-//line parser.go:4046
+//line parser.go:4053
 		switch data[p] {
 		case 33:
 			goto st95
@@ -4140,7 +4151,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof95
 		}
 	st_case_95:
-//line parser.go:4149
+//line parser.go:4156
 		switch data[p] {
 		case 33:
 			goto st96
@@ -4243,7 +4254,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof96
 		}
 	st_case_96:
-//line parser.go:4252
+//line parser.go:4259
 		switch data[p] {
 		case 33:
 			goto st97
@@ -4308,7 +4319,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof97
 		}
 	st_case_97:
-//line parser.go:4317
+//line parser.go:4324
 		switch data[p] {
 		case 33:
 			goto st46
@@ -4357,7 +4368,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof98
 		}
 	st_case_98:
-//line parser.go:4366
+//line parser.go:4373
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -4424,7 +4435,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof38
 		}
 	st_case_38:
-//line parser.go:4433
+//line parser.go:4440
 		if data[p] == 58 {
 			goto st5
 		}
@@ -4452,7 +4463,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof99
 		}
 	st_case_99:
-//line parser.go:4461
+//line parser.go:4468
 		switch data[p] {
 		case 33:
 			goto tr4
@@ -4535,7 +4546,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof100
 		}
 	st_case_100:
-//line parser.go:4544
+//line parser.go:4551
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -4586,7 +4597,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof101
 		}
 	st_case_101:
-//line parser.go:4595
+//line parser.go:4602
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -4695,7 +4706,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof103
 		}
 	st_case_103:
-//line parser.go:4704
+//line parser.go:4711
 		switch data[p] {
 		case 35:
 			goto tr84
@@ -4782,7 +4793,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof104
 		}
 	st_case_104:
-//line parser.go:4791
+//line parser.go:4798
 		switch data[p] {
 		case 33:
 			goto st46
@@ -4831,7 +4842,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof105
 		}
 	st_case_105:
-//line parser.go:4840
+//line parser.go:4847
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -4938,7 +4949,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof107
 		}
 	st_case_107:
-//line parser.go:4947
+//line parser.go:4954
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5045,7 +5056,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof109
 		}
 	st_case_109:
-//line parser.go:5054
+//line parser.go:5061
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5096,7 +5107,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof39
 		}
 	st_case_39:
-//line parser.go:5105
+//line parser.go:5112
 		switch data[p] {
 		case 58:
 			goto st40
@@ -5159,7 +5170,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof41
 		}
 	st_case_41:
-//line parser.go:5168
+//line parser.go:5175
 		if data[p] == 58 {
 			goto st5
 		}
@@ -5187,7 +5198,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof110
 		}
 	st_case_110:
-//line parser.go:5196
+//line parser.go:5203
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5256,7 +5267,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof111
 		}
 	st_case_111:
-//line parser.go:5265
+//line parser.go:5272
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5381,7 +5392,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof113
 		}
 	st_case_113:
-//line parser.go:5390
+//line parser.go:5397
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5494,7 +5505,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof115
 		}
 	st_case_115:
-//line parser.go:5503
+//line parser.go:5510
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5547,7 +5558,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof116
 		}
 	st_case_116:
-//line parser.go:5556
+//line parser.go:5563
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5598,7 +5609,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof42
 		}
 	st_case_42:
-//line parser.go:5607
+//line parser.go:5614
 		switch data[p] {
 		case 46:
 			goto st30
@@ -5629,7 +5640,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof117
 		}
 	st_case_117:
-//line parser.go:5638
+//line parser.go:5645
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5698,7 +5709,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof118
 		}
 	st_case_118:
-//line parser.go:5707
+//line parser.go:5714
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5823,7 +5834,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof120
 		}
 	st_case_120:
-//line parser.go:5832
+//line parser.go:5839
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -5894,7 +5905,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof121
 		}
 	st_case_121:
-//line parser.go:5903
+//line parser.go:5910
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -6037,7 +6048,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof124
 		}
 	st_case_124:
-//line parser.go:6046
+//line parser.go:6053
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -6146,7 +6157,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof126
 		}
 	st_case_126:
-//line parser.go:6155
+//line parser.go:6162
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -6195,7 +6206,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof127
 		}
 	st_case_127:
-//line parser.go:6204
+//line parser.go:6211
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -6266,7 +6277,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof128
 		}
 	st_case_128:
-//line parser.go:6275
+//line parser.go:6282
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -6469,7 +6480,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof132
 		}
 	st_case_132:
-//line parser.go:6478
+//line parser.go:6485
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -6520,7 +6531,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof133
 		}
 	st_case_133:
-//line parser.go:6529
+//line parser.go:6536
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -6589,7 +6600,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof134
 		}
 	st_case_134:
-//line parser.go:6598
+//line parser.go:6605
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -6698,7 +6709,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof43
 		}
 	st_case_43:
-//line parser.go:6707
+//line parser.go:6714
 		switch data[p] {
 		case 33:
 			goto st31
@@ -6784,7 +6795,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof136
 		}
 	st_case_136:
-//line parser.go:6793
+//line parser.go:6800
 		switch data[p] {
 		case 33:
 			goto st8
@@ -6933,7 +6944,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof139
 		}
 	st_case_139:
-//line parser.go:6942
+//line parser.go:6949
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -7042,7 +7053,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof141
 		}
 	st_case_141:
-//line parser.go:7051
+//line parser.go:7058
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -7169,7 +7180,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof143
 		}
 	st_case_143:
-//line parser.go:7178
+//line parser.go:7185
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -7240,7 +7251,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof144
 		}
 	st_case_144:
-//line parser.go:7249
+//line parser.go:7256
 		switch data[p] {
 		case 35:
 			goto tr57
@@ -7318,7 +7329,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof146
 		}
 	st_case_146:
-//line parser.go:7327
+//line parser.go:7334
 		switch data[p] {
 		case 33:
 			goto st46
@@ -7376,7 +7387,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof147
 		}
 	st_case_147:
-//line parser.go:7385
+//line parser.go:7392
 		switch data[p] {
 		case 33:
 			goto tr227
@@ -7473,7 +7484,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof148
 		}
 	st_case_148:
-//line parser.go:7482
+//line parser.go:7489
 		switch data[p] {
 		case 33:
 			goto st94
@@ -7544,7 +7555,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof149
 		}
 	st_case_149:
-//line parser.go:7553
+//line parser.go:7560
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -7613,7 +7624,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof150
 		}
 	st_case_150:
-//line parser.go:7622
+//line parser.go:7629
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -7684,7 +7695,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof151
 		}
 	st_case_151:
-//line parser.go:7693
+//line parser.go:7700
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -7755,7 +7766,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof152
 		}
 	st_case_152:
-//line parser.go:7764
+//line parser.go:7771
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -8012,7 +8023,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof157
 		}
 	st_case_157:
-//line parser.go:8021
+//line parser.go:8028
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -8081,7 +8092,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof158
 		}
 	st_case_158:
-//line parser.go:8090
+//line parser.go:8097
 		switch data[p] {
 		case 35:
 			goto tr64
@@ -8248,7 +8259,7 @@ func ParseURL(data string) (*URL, error) {
 			goto _test_eof161
 		}
 	st_case_161:
-//line parser.go:8257
+//line parser.go:8264
 		switch data[p] {
 		case 33:
 			goto st31
@@ -8849,7 +8860,7 @@ func ParseURL(data string) (*URL, error) {
 					u.Port = data[port_mark:p]
 				}
 
-//line parser.go:8536
+//line parser.go:8543
 			}
 		}
 
@@ -8858,10 +8869,31 @@ func ParseURL(data string) (*URL, error) {
 		}
 	}
 
-//line parser.rl:116
+//line parser.rl:127
 	if cs < url_parser_first_final {
-		return nil, fmt.Errorf("Failed to match URL")
-	} else {
-		return u, nil
+		return fmt.Errorf("Failed to match URL")
+	}
+
+	start := 0
+	if u.Protocol != "" {
+		// the host can't begin inside the scheme, the machine saves a bogus one for an empty authority
+		if host_mark == 0 {
+			u.Host = ""
+		}
+		start = len(u.Protocol) + 1
+		rest := data[start:]
+		u.opaque = len(rest) < 2 || rest[:2] != "//"
 	}
+	if !u.opaque && len(data) >= start+2 && data[start:start+2] == "//" {
+		start += 2
+	}
+	if u.Host != "" && host_mark > start && data[host_mark-1] == '@' {
+		u.User = data[start : host_mark-1]
+		// a port can't precede the host, the machine saves one for a colon in the userinfo
+		if port_mark < host_mark {
+			u.Port = ""
+		}
+	}
+
+	return nil
 }
@@ -98,25 +98,57 @@ import "fmt"
 // URL represents the different parts of a parsed URL
 type URL struct {
   Protocol string
+  User     string
   Host     string
   Port     string
   Path     string
   Query    string
   Fragment string
+
+  // opaque is set when the scheme is not followed by "//", as in "mailto:" or "data:"
+  opaque bool
 }
 
 // ParseURL parses a given URL and returns a `URL` representing the different parts
 func ParseURL(data string) (*URL, error){
+  u := &URL{}
+  if err := parseURL(data, u); err != nil {
+    return nil, err
+  }
+  return u, nil
+}
+
+// parseURL parses data into the zero value u, it doesn't allocate unless data fails to parse
+func parseURL(data string, u *URL) error {
   mark, host_mark, port_mark, cs, p, pe, eof := 0, 0, 0, url_parser_en_main, 0, len(data), len(data)
 
-  u := &URL{}
-  
   %% write init;
   %% write exec;
   if cs < url_parser_first_final {
-    return nil, fmt.Errorf("Failed to match URL")
-  } else {
-    return u, nil
+    return fmt.Errorf("Failed to match URL")
   }
+
+  start := 0
+  if u.Protocol != "" {
+    // the host can't begin inside the scheme, the machine saves a bogus one for an empty authority
+    if host_mark == 0 {
+      u.Host = ""
+    }
+    start = len(u.Protocol) + 1
+    rest := data[start:]
+    u.opaque = len(rest) < 2 || rest[:2] != "//"
+  }
+  if !u.opaque && len(data) >= start+2 && data[start:start+2] == "//" {
+    start += 2
+  }
+  if u.Host != "" && host_mark > start && data[host_mark-1] == '@' {
+    u.User = data[start : host_mark-1]
+    // a port can't precede the host, the machine saves one for a colon in the userinfo
+    if port_mark < host_mark {
+      u.Port = ""
+    }
+  }
+
+  return nil
 }
 
@@ -134,6 +134,7 @@ func TestParseURL(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, "example.com", url.Host)
+		assert.Equal(t, "John.Doe", url.User)
 		assert.Equal(t, "mailto", url.Protocol)
 		assert.Empty(t, url.Path)
 		assert.Empty(t, url.Port)
@@ -177,6 +178,26 @@ func TestParseURL(t *testing.T) {
 		assert.Empty(t, url.Fragment)
 	})
 
+	t.Run("Userinfo", func(t *testing.T) {
+		url, err := ParseURL("https://user:p@ss@example.com@evil.com:8443/x")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user:p@ss@example.com", url.User)
+		assert.Equal(t, "evil.com", url.Host)
+		assert.Equal(t, "8443", url.Port)
+		assert.Equal(t, "/x", url.Path)
+	})
+
+	t.Run("Empty authority", func(t *testing.T) {
+		url, err := ParseURL("file:///etc/passwd")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "file", url.Protocol)
+		assert.Equal(t, "/etc/passwd", url.Path)
+		assert.Empty(t, url.Host)
+		assert.Empty(t, url.Port)
+	})
+
 	t.Run("With query only", func(t *testing.T) {
 		url, err := ParseURL("http://example.com?foo=bar")
 
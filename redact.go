@@ -0,0 +1,101 @@
+package fasturl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultRedactParams are the query parameters whose values are redacted when a `RedactPolicy` doesn't list its own
+var DefaultRedactParams = []string{
+	"token",
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"client_secret",
+	"api_key",
+	"apikey",
+	"password",
+	"signature",
+	"sig",
+	"X-Amz-Signature",
+	"X-Amz-Security-Token",
+	"X-Goog-Signature",
+}
+
+// RedactPolicy configures what `Redact` and the `slog.LogValuer` of `URL` hide
+type RedactPolicy struct {
+	// Params are the query and fragment parameter names whose values are redacted, matched case insensitively.
+	// A nil slice means `DefaultRedactParams`.
+	Params []string
+	// Placeholder replaces every redacted value, defaults to "xxxxx"
+	Placeholder string
+}
+
+// DefaultRedactPolicy is used when a `URL` is logged with log/slog
+var DefaultRedactPolicy = RedactPolicy{}
+
+// Redact returns the string form of u with the password of the userinfo and the values of sensitive parameters replaced
+func Redact(u *URL, policy RedactPolicy) string {
+	r := *u
+	r.User = policy.redactUser(u.User)
+	r.Query = policy.redactParams(u.Query)
+	r.Fragment = policy.redactParams(u.Fragment)
+	return r.String()
+}
+
+func (p RedactPolicy) placeholder() string {
+	if p.Placeholder == "" {
+		return "xxxxx"
+	}
+	return p.Placeholder
+}
+
+// redactUser replaces the password of a "user:password" userinfo
+func (p RedactPolicy) redactUser(user string) string {
+	if i := strings.IndexByte(user, ':'); i >= 0 {
+		return user[:i+1] + p.placeholder()
+	}
+	return user
+}
+
+func (p RedactPolicy) sensitive(key string) bool {
+	if k, err := url.QueryUnescape(key); err == nil {
+		key = k
+	}
+	params := p.Params
+	if params == nil {
+		params = DefaultRedactParams
+	}
+	for _, param := range params {
+		if strings.EqualFold(key, param) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactParams replaces the values of sensitive parameters in an `&` separated list, leaving everything else untouched
+func (p RedactPolicy) redactParams(q string) string {
+	if q == "" {
+		return q
+	}
+
+	var sb strings.Builder
+	redacted := false
+	for i, param := range strings.Split(q, "&") {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		if j := strings.IndexByte(param, '='); j >= 0 && p.sensitive(param[:j]) {
+			sb.WriteString(param[:j+1])
+			sb.WriteString(p.placeholder())
+			redacted = true
+			continue
+		}
+		sb.WriteString(param)
+	}
+	if !redacted {
+		return q
+	}
+	return sb.String()
+}
@@ -0,0 +1,26 @@
+//go:build go1.21
+// +build go1.21
+
+package fasturl
+
+import "log/slog"
+
+// LogValue implements `slog.LogValuer`, logging the components of the URL as a group redacted with `DefaultRedactPolicy`
+func (u URL) LogValue() slog.Value {
+	p := DefaultRedactPolicy
+	attrs := make([]slog.Attr, 0, 7)
+	for _, c := range [...]struct{ key, value string }{
+		{"protocol", u.Protocol},
+		{"user", p.redactUser(u.User)},
+		{"host", u.Host},
+		{"port", u.Port},
+		{"path", u.Path},
+		{"query", p.redactParams(u.Query)},
+		{"fragment", p.redactParams(u.Fragment)},
+	} {
+		if c.value != "" {
+			attrs = append(attrs, slog.String(c.key, c.value))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,67 @@
+package fasturl
+
+import "strings"
+
+// Resolve parses ref and resolves it against u following RFC 3986 section 5.2.
+// The kind of reference is taken from ref itself since `ParseURL` reads a relative path such as "g/h" as a host.
+func (u *URL) Resolve(ref string) (*URL, error) {
+	r := &URL{}
+	if err := parseURLSplit(ref, r); err != nil {
+		return nil, err
+	}
+
+	t := &URL{}
+	switch {
+	case r.Protocol != "":
+		*t = *r
+		t.Path = removeDotSegments(r.Path)
+		return t, nil
+	case strings.HasPrefix(ref, "//"):
+		*t = *r
+		t.Protocol = u.Protocol
+		t.Path = removeDotSegments(r.Path)
+		return t, nil
+	}
+
+	t.Protocol, t.User, t.Host, t.Port, t.opaque = u.Protocol, u.User, u.Host, u.Port, u.opaque
+
+	path, query, fragment, hasQuery := splitRelativeRef(ref)
+	t.Fragment = fragment
+	switch {
+	case path == "":
+		t.Path = u.Path
+		t.Query = u.Query
+		if hasQuery {
+			t.Query = query
+		}
+	case path[0] == '/' || path[0] == '\\':
+		t.Path = removeDotSegments(path)
+		t.Query = query
+	default:
+		t.Path = removeDotSegments(mergePaths(u, path))
+		t.Query = query
+	}
+	return t, nil
+}
+
+// splitRelativeRef splits a relative-path or absolute-path reference into its path, query and fragment
+func splitRelativeRef(ref string) (path, query, fragment string, hasQuery bool) {
+	if i := strings.IndexByte(ref, '#'); i >= 0 {
+		ref, fragment = ref[:i], ref[i+1:]
+	}
+	if i := strings.IndexByte(ref, '?'); i >= 0 {
+		ref, query, hasQuery = ref[:i], ref[i+1:], true
+	}
+	return ref, query, fragment, hasQuery
+}
+
+// mergePaths implements RFC 3986 section 5.2.3
+func mergePaths(base *URL, path string) string {
+	if base.Host != "" && base.Path == "" {
+		return "/" + path
+	}
+	if i := strings.LastIndexAny(base.Path, "/\\"); i >= 0 {
+		return base.Path[:i+1] + path
+	}
+	return path
+}
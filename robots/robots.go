@@ -0,0 +1,358 @@
+// Package robots parses robots.txt files and matches URLs against them following RFC 9309,
+// with the extensions of Google's reference parser such as the Crawl-delay and Sitemap lines.
+package robots
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ImVexed/fasturl"
+)
+
+// Robots is a parsed robots.txt file
+type Robots struct {
+	groups   []*group
+	sitemaps []string
+}
+
+// group is a run of user-agent lines and the rules that follow them
+type group struct {
+	agents     []string
+	global     bool
+	rules      []rule
+	crawlDelay time.Duration
+	hasDelay   bool
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// Group holds the rules that apply to a single crawler, the zero value allows everything
+type Group struct {
+	rules      []rule
+	crawlDelay time.Duration
+	hasDelay   bool
+}
+
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+// Parse parses a robots.txt file, lines it doesn't understand are ignored as RFC 9309 requires.
+// Callers should stop reading the file after at least 500 KiB.
+func Parse(data []byte) *Robots {
+	// skip a byte order mark, even a truncated one
+	for i := 0; i < len(bom) && len(data) > 0 && data[0] == bom[i]; i++ {
+		data = data[1:]
+	}
+
+	r := &Robots{}
+	var current *group
+	collectingAgents := false
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			crlf := data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n'
+			line, data = data[:i], data[i+1:]
+			if crlf {
+				data = data[1:]
+			}
+		} else {
+			data = nil
+		}
+
+		key, value, ok := splitLine(string(line))
+		if !ok {
+			continue
+		}
+		switch keyKind(key) {
+		case keyUserAgent:
+			if !collectingAgents {
+				current = &group{}
+				r.groups = append(r.groups, current)
+				collectingAgents = true
+			}
+			if value == "*" || strings.HasPrefix(value, "*") && isSpace(value[1]) {
+				current.global = true
+			} else if agent := productToken(value); agent != "" {
+				current.agents = append(current.agents, agent)
+			}
+		case keyAllow, keyDisallow:
+			if current == nil {
+				continue
+			}
+			collectingAgents = false
+			if value == "" {
+				// an empty pattern matches nothing
+				continue
+			}
+			allow := keyKind(key) == keyAllow
+			pattern := escape(value)
+			current.rules = append(current.rules, rule{allow, pattern})
+			if allow {
+				// Google treats an allowed index page as allowing its directory
+				slash := strings.LastIndexByte(pattern, '/')
+				if slash >= 0 && strings.HasPrefix(pattern[slash:], "/index.htm") {
+					current.rules = append(current.rules, rule{true, pattern[:slash+1] + "$"})
+				}
+			}
+		case keyCrawlDelay:
+			if current == nil {
+				continue
+			}
+			collectingAgents = false
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs >= 0 && !math.IsInf(secs, 0) && !current.hasDelay {
+				current.crawlDelay = time.Duration(secs * float64(time.Second))
+				current.hasDelay = true
+			}
+		case keySitemap:
+			if value != "" {
+				r.sitemaps = append(r.sitemaps, value)
+			}
+		}
+	}
+	return r
+}
+
+type lineKey int
+
+const (
+	keyUnknown lineKey = iota
+	keyUserAgent
+	keyAllow
+	keyDisallow
+	keyCrawlDelay
+	keySitemap
+)
+
+// keyKind classifies a line key, accepting the common typos Google's parser accepts
+func keyKind(key string) lineKey {
+	switch strings.ToLower(key) {
+	case "user-agent", "useragent", "user agent":
+		return keyUserAgent
+	case "allow":
+		return keyAllow
+	case "disallow", "dissallow", "dissalow", "disalow", "diasllow", "disallaw":
+		return keyDisallow
+	case "crawl-delay":
+		return keyCrawlDelay
+	case "sitemap", "site-map":
+		return keySitemap
+	}
+	return keyUnknown
+}
+
+// splitLine strips the comment from line and splits it into its key and value.
+// A line without a colon is accepted when it holds exactly two words, as in "Disallow /".
+func splitLine(line string) (key, value string, ok bool) {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	line = strings.TrimSpace(line)
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		key, value = line[:i], line[i+1:]
+	} else {
+		i := strings.IndexAny(line, " \t")
+		if i < 0 {
+			return "", "", false
+		}
+		key, value = line[:i], strings.TrimSpace(line[i:])
+		if strings.ContainsAny(value, " \t") {
+			return "", "", false
+		}
+	}
+	key = strings.TrimSpace(key)
+	return key, strings.TrimSpace(value), key != ""
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// productToken returns the leading [A-Za-z_-] characters of a user agent, "Googlebot/2.1" becomes "Googlebot"
+func productToken(agent string) string {
+	for i := 0; i < len(agent); i++ {
+		c := agent[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '-' || c == '_') {
+			return agent[:i]
+		}
+	}
+	return agent
+}
+
+// IsValidUserAgent reports whether agent is a product token a crawler can be identified by, made only of letters, "-" and "_"
+func IsValidUserAgent(agent string) bool {
+	return agent != "" && productToken(agent) == agent
+}
+
+// Sitemaps returns the values of the Sitemap lines, which don't belong to any group
+func (r *Robots) Sitemaps() []string {
+	return r.sitemaps
+}
+
+// Group returns the rules for userAgent, merging every group naming it or, when none does, every "*" group.
+// Only the product token of userAgent is compared, case-insensitively.
+func (r *Robots) Group(userAgent string) *Group {
+	agent := productToken(userAgent)
+	g := &Group{}
+	matched := false
+	for _, grp := range r.groups {
+		for _, a := range grp.agents {
+			if agent != "" && strings.EqualFold(a, agent) {
+				g.merge(grp)
+				matched = true
+				break
+			}
+		}
+	}
+	if !matched {
+		for _, grp := range r.groups {
+			if grp.global {
+				g.merge(grp)
+			}
+		}
+	}
+	return g
+}
+
+func (g *Group) merge(grp *group) {
+	g.rules = append(g.rules, grp.rules...)
+	if grp.hasDelay && !g.hasDelay {
+		g.crawlDelay, g.hasDelay = grp.crawlDelay, true
+	}
+}
+
+// Allowed reports whether userAgent may crawl u, see `Group.Allowed`
+func (r *Robots) Allowed(userAgent string, u *fasturl.URL) bool {
+	return r.Group(userAgent).Allowed(u)
+}
+
+// Allowed reports whether u may be crawled, matching its path and query
+func (g *Group) Allowed(u *fasturl.URL) bool {
+	if u.Query == "" {
+		return g.AllowedPath(u.Path)
+	}
+	return g.AllowedPath(u.Path + "?" + u.Query)
+}
+
+// AllowedPath reports whether a path, with its query if any, may be crawled.
+// The longest matching rule wins and Allow wins ties. Both the path and the patterns are compared
+// with non-ASCII bytes percent-encoded and percent-encoded unreserved characters decoded.
+func (g *Group) AllowedPath(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	path = escape(path)
+
+	allow, disallow := -1, -1
+	for _, r := range g.rules {
+		if r.allow && len(r.pattern) > allow && match(path, r.pattern) {
+			allow = len(r.pattern)
+		} else if !r.allow && len(r.pattern) > disallow && match(path, r.pattern) {
+			disallow = len(r.pattern)
+		}
+	}
+	return disallow <= allow
+}
+
+// CrawlDelay returns the value of the first Crawl-delay line of the group
+func (g *Group) CrawlDelay() (time.Duration, bool) {
+	return g.crawlDelay, g.hasDelay
+}
+
+// match reports whether pattern matches a prefix of path, or all of it when the pattern ends with "$".
+// A "*" matches any sequence of characters.
+func match(path, pattern string) bool {
+	// the candidate positions in path after the pattern so far, kept sorted
+	var stack [16]int
+	pos := append(stack[:0], 0)
+	for j := 0; j < len(pattern); j++ {
+		switch c := pattern[j]; {
+		case c == '$' && j == len(pattern)-1:
+			return pos[len(pos)-1] == len(path)
+		case c == '*':
+			first := pos[0]
+			pos = pos[:0]
+			for i := first; i <= len(path); i++ {
+				pos = append(pos, i)
+			}
+		default:
+			next := pos[:0]
+			for _, p := range pos {
+				if p < len(path) && path[p] == c {
+					next = append(next, p+1)
+				}
+			}
+			if len(next) == 0 {
+				return false
+			}
+			pos = next
+		}
+	}
+	return true
+}
+
+// escape percent-encodes non-ASCII bytes, decodes percent-encoded unreserved characters and upper cases other escapes
+func escape(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		if s[i] == '%' || s[i] >= 0x80 {
+			break
+		}
+	}
+	if i == len(s) {
+		return s
+	}
+
+	const upperHex = "0123456789ABCDEF"
+	var sb strings.Builder
+	sb.Grow(len(s) + 8)
+	sb.WriteString(s[:i])
+	for ; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 0x80:
+			sb.WriteByte('%')
+			sb.WriteByte(upperHex[c>>4])
+			sb.WriteByte(upperHex[c&15])
+		case c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			d := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(d) {
+				sb.WriteByte(d)
+			} else {
+				sb.WriteByte('%')
+				sb.WriteByte(upperHex[d>>4])
+				sb.WriteByte(upperHex[d&15])
+			}
+			i += 2
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
@@ -0,0 +1,358 @@
+package robots
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ImVexed/fasturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isUserAgentAllowed mirrors the helper of the test suite of Google's robotstxt library
+func isUserAgentAllowed(t *testing.T, robotstxt, agent, rawURL string) bool {
+	u, err := fasturl.ParseURL(rawURL)
+	require.NoError(t, err, rawURL)
+	return Parse([]byte(robotstxt)).Allowed(agent, u)
+}
+
+// The following tests are transcribed from robots_test.cc of github.com/google/robotstxt
+
+func TestGoogleLineSyntax(t *testing.T) {
+	const url = "http://foo.bar/x/y"
+
+	t.Run("Line", func(t *testing.T) {
+		assert.False(t, isUserAgentAllowed(t, "user-agent: FooBot\ndisallow: /\n", "FooBot", url))
+		assert.True(t, isUserAgentAllowed(t, "foo: FooBot\nbar: /\n", "FooBot", url))
+		assert.False(t, isUserAgentAllowed(t, "user-agent FooBot\ndisallow /\n", "FooBot", url))
+	})
+
+	t.Run("Groups", func(t *testing.T) {
+		const robotstxt = "allow: /foo/bar/\n" +
+			"\n" +
+			"user-agent: FooBot\n" +
+			"disallow: /\n" +
+			"allow: /x/\n" +
+			"user-agent: BarBot\n" +
+			"disallow: /\n" +
+			"allow: /y/\n" +
+			"\n" +
+			"\n" +
+			"allow: /w/\n" +
+			"user-agent: BazBot\n" +
+			"\n" +
+			"user-agent: FooBot\n" +
+			"allow: /z/\n" +
+			"disallow: /\n"
+		const (
+			urlW   = "http://foo.bar/w/a"
+			urlX   = "http://foo.bar/x/b"
+			urlY   = "http://foo.bar/y/c"
+			urlZ   = "http://foo.bar/z/d"
+			urlFoo = "http://foo.bar/foo/bar/"
+		)
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", urlX))
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", urlZ))
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", urlY))
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "BarBot", urlY))
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "BarBot", urlW))
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "BarBot", urlZ))
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "BazBot", urlZ))
+
+		// lines with rules outside groups are ignored
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", urlFoo))
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "BarBot", urlFoo))
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "BazBot", urlFoo))
+	})
+
+	t.Run("Groups other rules", func(t *testing.T) {
+		// Sitemap and unknown lines don't end the user-agent lines of a group
+		robotstxt := "User-agent: BarBot\n" +
+			"Sitemap: https://foo.bar/sitemap\n" +
+			"User-agent: *\n" +
+			"Disallow: /\n"
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", url))
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "BarBot", url))
+
+		robotstxt = "User-agent: FooBot\n" +
+			"Invalid-Unknown-Line: unknown\n" +
+			"User-agent: *\n" +
+			"Disallow: /\n"
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", url))
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "BarBot", url))
+	})
+}
+
+func TestGoogleREPLineNamesCaseInsensitive(t *testing.T) {
+	const url = "http://foo.bar/x/y"
+	for _, robotstxt := range []string{
+		"USER-AGENT: FooBot\nALLOW: /x/\nDISALLOW: /\n",
+		"user-agent: FooBot\nallow: /x/\ndisallow: /\n",
+		"uSeR-aGeNt: FooBot\nAlLoW: /x/\ndIsAlLoW: /\n",
+	} {
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", url), robotstxt)
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/a/b"), robotstxt)
+	}
+}
+
+func TestGoogleVerifyValidUserAgentsToObey(t *testing.T) {
+	assert.True(t, IsValidUserAgent("Foobot"))
+	assert.True(t, IsValidUserAgent("Foobot-Bar"))
+	assert.True(t, IsValidUserAgent("Foo_Bar"))
+
+	assert.False(t, IsValidUserAgent(""))
+	assert.False(t, IsValidUserAgent("ツ"))
+	assert.False(t, IsValidUserAgent("Foobot*"))
+	assert.False(t, IsValidUserAgent(" Foobot "))
+	assert.False(t, IsValidUserAgent("Foobot/2.1"))
+	assert.False(t, IsValidUserAgent("Foobot Bar"))
+}
+
+func TestGoogleUserAgentValueCaseInsensitive(t *testing.T) {
+	const url = "http://foo.bar/x/y"
+	for _, robotstxt := range []string{
+		"User-Agent: FOO BAR\nAllow: /x/\nDisallow: /\n",
+		"User-Agent: foo bar\nAllow: /x/\nDisallow: /\n",
+		"User-Agent: FoO bAr\nAllow: /x/\nDisallow: /\n",
+	} {
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "Foo", url), robotstxt)
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "foo", url), robotstxt)
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "Foo", "http://foo.bar/a/b"), robotstxt)
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "foo", "http://foo.bar/a/b"), robotstxt)
+	}
+}
+
+func TestGoogleAcceptUserAgentUpToFirstSpace(t *testing.T) {
+	const robotstxt = "User-Agent: *\n" +
+		"Disallow: /\n" +
+		"User-Agent: Foo Bar\n" +
+		"Allow: /x/\n" +
+		"Disallow: /\n"
+	const url = "http://foo.bar/x/y"
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "Foo", url))
+	assert.False(t, isUserAgentAllowed(t, robotstxt, "Bar", url))
+}
+
+func TestGoogleGlobalGroupsSecondary(t *testing.T) {
+	const (
+		empty        = ""
+		global       = "user-agent: *\nallow: /\nuser-agent: FooBot\ndisallow: /\n"
+		onlySpecific = "user-agent: FooBot\nallow: /\nuser-agent: BarBot\ndisallow: /\nuser-agent: BazBot\ndisallow: /\n"
+		url          = "http://foo.bar/x/y"
+	)
+	assert.True(t, isUserAgentAllowed(t, empty, "FooBot", url))
+	assert.False(t, isUserAgentAllowed(t, global, "FooBot", url))
+	assert.True(t, isUserAgentAllowed(t, global, "BarBot", url))
+	assert.True(t, isUserAgentAllowed(t, onlySpecific, "QuxBot", url))
+}
+
+func TestGoogleAllowDisallowValueCaseSensitive(t *testing.T) {
+	const url = "http://foo.bar/x/y"
+	assert.False(t, isUserAgentAllowed(t, "user-agent: FooBot\ndisallow: /x/\n", "FooBot", url))
+	assert.True(t, isUserAgentAllowed(t, "user-agent: FooBot\ndisallow: /X/\n", "FooBot", url))
+}
+
+func TestGoogleLongestMatch(t *testing.T) {
+	const url = "http://foo.bar/x/page.html"
+	assert.True(t, isUserAgentAllowed(t, "user-agent: FooBot\ndisallow: /x/page.html\nallow: /x/page.html\n", "FooBot", url))
+	assert.True(t, isUserAgentAllowed(t, "user-agent: FooBot\nallow: /x/page.html\ndisallow: /x/page.html\n", "FooBot", url))
+	assert.False(t, isUserAgentAllowed(t, "user-agent: FooBot\ndisallow: /x/page.html\nallow: /x/\n", "FooBot", url))
+	assert.True(t, isUserAgentAllowed(t, "user-agent: FooBot\ndisallow: /x/\nallow: /x/page.html\n", "FooBot", url))
+
+	const robotstxt = "user-agent: FooBot\ndisallow: \nallow: \n"
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/"))
+
+	assert.True(t, isUserAgentAllowed(t, "user-agent: FooBot\ndisallow: /\nallow: /\n", "FooBot", "http://foo.bar/"))
+
+	ambiguous := "user-agent: FooBot\ndisallow: /x\nallow: /x/\n"
+	assert.False(t, isUserAgentAllowed(t, ambiguous, "FooBot", "http://foo.bar/x"))
+	assert.True(t, isUserAgentAllowed(t, ambiguous, "FooBot", "http://foo.bar/x/"))
+
+	precedence := "user-agent: FooBot\nallow: /page\ndisallow: /*.html\n"
+	assert.False(t, isUserAgentAllowed(t, precedence, "FooBot", "http://foo.bar/page.html"))
+	assert.True(t, isUserAgentAllowed(t, precedence, "FooBot", "http://foo.bar/page"))
+
+	precedence = "user-agent: FooBot\nallow: /x/page.\ndisallow: /*.html\n"
+	assert.True(t, isUserAgentAllowed(t, precedence, "FooBot", url))
+	assert.False(t, isUserAgentAllowed(t, precedence, "FooBot", "http://foo.bar/x/y.html"))
+
+	specific := "User-agent: *\nDisallow: /x/\nUser-agent: FooBot\nDisallow: /y/\n"
+	assert.True(t, isUserAgentAllowed(t, specific, "FooBot", "http://foo.bar/x/page"))
+	assert.False(t, isUserAgentAllowed(t, specific, "FooBot", "http://foo.bar/y/page"))
+}
+
+func TestGoogleEncoding(t *testing.T) {
+	// /foo/bar?baz=http://foo.bar stays unencoded, fasturl can't parse such a query so the path is matched directly
+	g := Parse([]byte("User-agent: FooBot\nDisallow: /\nAllow: /foo/bar?qux=taz&baz=http://foo.bar?tar&par\n")).Group("FooBot")
+	assert.True(t, g.AllowedPath("/foo/bar?qux=taz&baz=http://foo.bar?tar&par"))
+
+	// 3 byte character: /foo/bar/ツ -> /foo/bar/%E3%83%84
+	robotstxt := "User-agent: FooBot\nDisallow: /\nAllow: /foo/bar/ツ\n"
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/%E3%83%84"))
+	// Google expects the URL to be encoded already and disallows the raw form, RFC 9309 encodes both sides
+	assert.True(t, Parse([]byte(robotstxt)).Group("FooBot").AllowedPath("/foo/bar/ツ"))
+
+	// Percent encoded 3 byte character: /foo/bar/%E3%83%84 -> /foo/bar/%E3%83%84
+	robotstxt = "User-agent: FooBot\nDisallow: /\nAllow: /foo/bar/%E3%83%84\n"
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/%E3%83%84"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/%e3%83%84"))
+
+	// Percent encoded unreserved US-ASCII: /foo/bar/%62%61%7A -> /foo/bar/baz.
+	// Google leaves it encoded and disallows /foo/bar/baz, RFC 9309 requires decoding it before comparing.
+	robotstxt = "User-agent: FooBot\nDisallow: /\nAllow: /foo/bar/%62%61%7A\n"
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/baz"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/%62%61%7A"))
+}
+
+func TestGoogleSpecialCharacters(t *testing.T) {
+	robotstxt := "User-agent: FooBot\nDisallow: /foo/bar/quz\nAllow: /foo/*/qux\n"
+	assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/quz"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/quz"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo//quz"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bax/quz"))
+
+	robotstxt = "User-agent: FooBot\nDisallow: /foo/bar$\nAllow: /foo/bar/qux\n"
+	assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/qux"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar/baz"))
+
+	robotstxt = "User-agent: FooBot\n# Disallow: /\nDisallow: /foo/quz#qux\nAllow: /\n"
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/bar"))
+	assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar/foo/quz"))
+}
+
+func TestGoogleIndexHTMLisDirectory(t *testing.T) {
+	const robotstxt = "User-Agent: *\nAllow: /allowed-slash/index.html\nDisallow: /\n"
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "foobot", "http://foo.com/allowed-slash/"))
+	assert.False(t, isUserAgentAllowed(t, robotstxt, "foobot", "http://foo.com/allowed-slash/index.htm"))
+	assert.True(t, isUserAgentAllowed(t, robotstxt, "foobot", "http://foo.com/allowed-slash/index.html"))
+	assert.False(t, isUserAgentAllowed(t, robotstxt, "foobot", "http://foo.com/anyother-url"))
+}
+
+func TestGoogleDocumentationChecks(t *testing.T) {
+	for _, tc := range []struct {
+		pattern    string
+		disallowed []string
+		allowed    []string
+	}{
+		{
+			"/fish",
+			[]string{"/fish", "/fish.html", "/fish/salmon.html", "/fishheads", "/fishheads/yummy.html", "/fish.html?id=anything"},
+			[]string{"/Fish.asp", "/catfish", "/?id=fish", "/bar"},
+		},
+		{
+			"/fish*",
+			[]string{"/fish", "/fish.html", "/fish/salmon.html", "/fishheads", "/fishheads/yummy.html", "/fish.html?id=anything"},
+			[]string{"/Fish.bar", "/catfish", "/?id=fish", "/bar"},
+		},
+		{
+			"/fish/",
+			[]string{"/fish/", "/fish/?id=anything", "/fish/salmon.htm"},
+			[]string{"/fish", "/fish.html", "/Fish/Salmon.html", "/bar"},
+		},
+		{
+			"/*.php",
+			[]string{"/filename.php", "/folder/filename.php", "/folder/filename.php?parameters", "/folder/any.php.file.html", "/filename.php/"},
+			[]string{"/", "/windows.PHP", "/bar"},
+		},
+		{
+			"/*.php$",
+			[]string{"/filename.php", "/folder/filename.php"},
+			[]string{"/filename.php?parameters", "/filename.php/", "/filename.php5", "/windows.PHP", "/bar"},
+		},
+		{
+			"/fish*.php",
+			[]string{"/fish.php", "/fishheads/catfish.php?parameters"},
+			[]string{"/Fish.PHP", "/bar"},
+		},
+	} {
+		robotstxt := "user-agent: FooBot\ndisallow: /\nallow: " + tc.pattern + "\n"
+		for _, path := range tc.disallowed {
+			assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar"+path), tc.pattern+" "+path)
+		}
+		robotstxt = "user-agent: FooBot\ndisallow: " + tc.pattern + "\n"
+		for _, path := range tc.disallowed {
+			assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar"+path), tc.pattern+" "+path)
+		}
+		for _, path := range tc.allowed {
+			assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://foo.bar"+path), tc.pattern+" "+path)
+		}
+	}
+
+	t.Run("Order of precedence", func(t *testing.T) {
+		assert.True(t, isUserAgentAllowed(t, "user-agent: FooBot\nallow: /p\ndisallow: /\n", "FooBot", "http://example.com/page"))
+		assert.True(t, isUserAgentAllowed(t, "user-agent: FooBot\nallow: /folder\ndisallow: /folder\n", "FooBot", "http://example.com/folder/page"))
+		assert.False(t, isUserAgentAllowed(t, "user-agent: FooBot\nallow: /page\ndisallow: /*.htm\n", "FooBot", "http://example.com/page.htm"))
+
+		robotstxt := "user-agent: FooBot\nallow: /$\ndisallow: /\n"
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://example.com/"))
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "FooBot", "http://example.com/page.html"))
+	})
+}
+
+func TestGoogleLineEndings(t *testing.T) {
+	const url = "http://foo.bar/x/y"
+	for _, robotstxt := range []string{
+		"User-Agent: foo\nAllow: /some/path\nUser-Agent: bar\n\n\nDisallow: /\n",
+		"User-Agent: foo\r\nAllow: /some/path\r\nUser-Agent: bar\r\n\r\n\r\nDisallow: /\r\n",
+		"User-Agent: foo\rAllow: /some/path\rUser-Agent: bar\r\r\rDisallow: /\r",
+		"User-Agent: foo\nAllow: /some/path\r\nUser-Agent: bar\n\r\n\rDisallow: /",
+	} {
+		assert.False(t, isUserAgentAllowed(t, robotstxt, "bar", url), "%q", robotstxt)
+		assert.True(t, isUserAgentAllowed(t, robotstxt, "foo", url), "%q", robotstxt)
+	}
+}
+
+func TestGoogleBOM(t *testing.T) {
+	const url = "http://foo.bar/AnyValue"
+	assert.True(t, isUserAgentAllowed(t, "\xEF\xBB\xBFUser-Agent: foo\nAllow: /AnyValue\nDisallow: /\n", "foo", url))
+	assert.True(t, isUserAgentAllowed(t, "\xEF\xBBUser-Agent: foo\nAllow: /AnyValue\nDisallow: /\n", "foo", url))
+	assert.True(t, isUserAgentAllowed(t, "\xEFUser-Agent: foo\nAllow: /AnyValue\nDisallow: /\n", "foo", url))
+	// a broken BOM makes the first line unparsable
+	assert.True(t, isUserAgentAllowed(t, "\xEF\x11\xBFUser-Agent: foo\nDisallow: /AnyValue\n", "foo", url))
+	// a BOM in the middle of the file is part of the line
+	assert.True(t, isUserAgentAllowed(t, "User-Agent: foo\n\xEF\xBB\xBFDisallow: /AnyValue\n", "foo", url))
+}
+
+func TestGoogleNonStandardLineExampleSitemap(t *testing.T) {
+	const sitemap = "http://foo.bar/sitemap.xml"
+	r := Parse([]byte("User-Agent: foo\nAllow: /some/path\nUser-Agent: bar\n\n\nSitemap: " + sitemap + "\n"))
+	assert.Equal(t, []string{sitemap}, r.Sitemaps())
+
+	r = Parse([]byte("sitemap: " + sitemap + "\nUser-Agent: foo\nAllow: /some/path\nsite-map: /other.xml\n"))
+	assert.Equal(t, []string{sitemap, "/other.xml"}, r.Sitemaps())
+}
+
+func TestRobots(t *testing.T) {
+	r := Parse([]byte("User-agent: FooBot\n" +
+		"Crawl-delay: 2.5\n" +
+		"Disallow: /private\n" +
+		"\n" +
+		"User-agent: *\n" +
+		"Crawl-delay: nope\n" +
+		"Disallow: /\n"))
+
+	t.Run("CrawlDelay", func(t *testing.T) {
+		d, ok := r.Group("FooBot/1.2 (+https://foo.bar/bot)").CrawlDelay()
+		assert.True(t, ok)
+		assert.Equal(t, 2500*time.Millisecond, d)
+		_, ok = r.Group("BarBot").CrawlDelay()
+		assert.False(t, ok)
+	})
+
+	t.Run("Allowed", func(t *testing.T) {
+		assert.True(t, isUserAgentAllowed(t, "User-agent: *\nDisallow: /\n", "FooBot", "http://foo.bar/robots.txt"))
+		assert.True(t, isUserAgentAllowed(t, "User-agent: *\nDisallow: /x\n", "FooBot", "http://foo.bar"))
+		assert.False(t, isUserAgentAllowed(t, "User-agent: *\nDisallow: /*?\n", "FooBot", "http://foo.bar/a?b=c"))
+		assert.True(t, isUserAgentAllowed(t, "User-agent: *\nDisallow: /*?\n", "FooBot", "http://foo.bar/a#?"))
+		assert.True(t, isUserAgentAllowed(t, "User-agent: *\nDisallow: /a%2Fb\n", "FooBot", "http://foo.bar/a/b"))
+		assert.False(t, isUserAgentAllowed(t, "User-agent: *\nDisallow: /a%2fb\n", "FooBot", "http://foo.bar/a%2Fb"))
+
+		g := r.Group("foobot")
+		assert.False(t, g.AllowedPath("/private/x"))
+		assert.True(t, g.AllowedPath("/public"))
+		assert.False(t, r.Group("BarBot").AllowedPath("/public"))
+		assert.True(t, (&Group{}).AllowedPath("/anything"))
+	})
+}
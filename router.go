@@ -0,0 +1,288 @@
+package fasturl
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Param is a single path parameter captured by a `Router`
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params are the parameters captured for a request in the order they appear in the pattern
+type Params []Param
+
+// Get returns the value of the named parameter, or "" when there is none
+func (ps Params) Get(name string) string {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// RouteHandler serves a routed request, u and ps are reused once it returns so they must be copied to be kept
+type RouteHandler func(w http.ResponseWriter, r *http.Request, u *URL, ps Params)
+
+// Router is an `http.Handler` dispatching on the host, method and path of the request target.
+// Patterns are paths such as "/users/:id/files/*path", optionally prefixed by a host as in "api.example.com/users/:id".
+// The host is that of an absolute-form target, otherwise the Host header.
+// A ":name" segment captures a single path segment and a trailing "*name" captures the rest of the path.
+// Static segments take precedence over parameters which take precedence over wildcards.
+// Captured values are as they appear in the request target, still percent-encoded.
+type Router struct {
+	// NotFound handles requests no route matches, defaults to `http.NotFound`
+	NotFound http.Handler
+	// MethodNotAllowed handles requests whose path only matches routes of other methods,
+	// defaults to a 405 listing the allowed methods in the Allow header
+	MethodNotAllowed http.Handler
+	// BadRequest handles requests whose target doesn't parse, defaults to a plain 400
+	BadRequest http.Handler
+
+	hosts     []routeHost
+	maxParams int
+	pool      sync.Pool
+}
+
+type routeHost struct {
+	host string
+	root *routeNode
+}
+
+// routeNode is a path segment in the routing tree
+type routeNode struct {
+	static   map[string]*routeNode
+	param    *routeNode
+	wildcard *routeNode
+	// name is the parameter name of param and wildcard nodes
+	name     string
+	handlers map[string]RouteHandler
+}
+
+type routeContext struct {
+	u  URL
+	ps Params
+}
+
+// NewRouter returns an empty `Router`
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle registers h for method and pattern, an empty method matches any method not registered explicitly.
+// It panics when the pattern is malformed or conflicts with one already registered.
+func (rt *Router) Handle(method, pattern string, h RouteHandler) {
+	host, path := pattern, ""
+	if i := strings.IndexByte(pattern, '/'); i >= 0 {
+		host, path = pattern[:i], pattern[i:]
+	}
+	if path == "" {
+		panic("fasturl: route pattern " + pattern + " has no path")
+	}
+
+	n, params := rt.hostRoot(strings.ToLower(host)), 0
+	for rest := path[1:]; ; {
+		seg := rest
+		i := strings.IndexByte(rest, '/')
+		if i >= 0 {
+			seg, rest = rest[:i], rest[i+1:]
+		}
+
+		switch {
+		case strings.HasPrefix(seg, ":"):
+			n = n.paramChild(pattern, seg[1:])
+			params++
+		case strings.HasPrefix(seg, "*"):
+			if i >= 0 {
+				panic("fasturl: wildcard must be the last segment of route pattern " + pattern)
+			}
+			if n.wildcard == nil {
+				n.wildcard = &routeNode{name: seg[1:]}
+			} else if n.wildcard.name != seg[1:] {
+				panic("fasturl: wildcard " + seg + " conflicts with *" + n.wildcard.name + " in route pattern " + pattern)
+			}
+			n = n.wildcard
+			params++
+		default:
+			if n.static == nil {
+				n.static = map[string]*routeNode{}
+			}
+			child, ok := n.static[seg]
+			if !ok {
+				child = &routeNode{}
+				n.static[seg] = child
+			}
+			n = child
+		}
+		if i < 0 {
+			break
+		}
+	}
+
+	if n.handlers == nil {
+		n.handlers = map[string]RouteHandler{}
+	}
+	if _, ok := n.handlers[method]; ok {
+		panic("fasturl: route " + method + " " + pattern + " is already registered")
+	}
+	n.handlers[method] = h
+	if params > rt.maxParams {
+		rt.maxParams = params
+	}
+}
+
+// Handler registers a standard `http.Handler`, see `Router.Handle`
+func (rt *Router) Handler(method, pattern string, h http.Handler) {
+	rt.Handle(method, pattern, func(w http.ResponseWriter, r *http.Request, _ *URL, _ Params) {
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (rt *Router) hostRoot(host string) *routeNode {
+	for _, h := range rt.hosts {
+		if h.host == host {
+			return h.root
+		}
+	}
+	root := &routeNode{}
+	rt.hosts = append(rt.hosts, routeHost{host, root})
+	// the catch-all host goes last so explicit hosts are tried first
+	sort.SliceStable(rt.hosts, func(i, j int) bool { return rt.hosts[i].host != "" && rt.hosts[j].host == "" })
+	return root
+}
+
+func (n *routeNode) paramChild(pattern, name string) *routeNode {
+	if name == "" {
+		panic("fasturl: unnamed parameter in route pattern " + pattern)
+	}
+	if n.param == nil {
+		n.param = &routeNode{name: name}
+	} else if n.param.name != name {
+		panic("fasturl: parameter :" + name + " conflicts with :" + n.param.name + " in route pattern " + pattern)
+	}
+	return n.param
+}
+
+// ServeHTTP parses the request target and dispatches it, it doesn't allocate for requests that match a route
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, _ := rt.pool.Get().(*routeContext)
+	if ctx == nil {
+		ctx = &routeContext{}
+	}
+	defer rt.pool.Put(ctx)
+	ctx.u = URL{}
+
+	target := r.RequestURI
+	if target == "" {
+		target = r.URL.RequestURI()
+	}
+	bad := strings.IndexByte(target, '#') >= 0
+	if !bad && target != "" && target[0] == '/' {
+		// origin-form is a path, the parser would read "//host/x" as an authority to route on
+		ctx.u.Path, ctx.u.Query, _, _ = splitRelativeRef(target)
+	} else if !bad {
+		bad = parseURLSplit(target, &ctx.u) != nil || ctx.u.Host == ""
+	}
+	if bad || ctx.u.Path == "" || ctx.u.Path[0] != '/' {
+		if rt.BadRequest != nil {
+			rt.BadRequest.ServeHTTP(w, r)
+		} else {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		}
+		return
+	}
+
+	host := ctx.u.Host
+	if host == "" {
+		host = stripPort(r.Host)
+	}
+
+	for _, h := range rt.hosts {
+		if h.host != "" && !strings.EqualFold(h.host, strings.TrimSuffix(host, ".")) {
+			continue
+		}
+		if cap(ctx.ps) < rt.maxParams {
+			ctx.ps = make(Params, 0, rt.maxParams)
+		}
+		n, ps := h.root.lookup(ctx.u.Path[1:], ctx.ps[:0])
+		if n == nil {
+			continue
+		}
+		handler, ok := n.handlers[r.Method]
+		if !ok {
+			handler, ok = n.handlers[""]
+		}
+		if ok {
+			ctx.ps = ps
+			handler(w, r, &ctx.u, ps)
+			return
+		}
+		rt.methodNotAllowed(w, r, n)
+		return
+	}
+
+	if rt.NotFound != nil {
+		rt.NotFound.ServeHTTP(w, r)
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+func (rt *Router) methodNotAllowed(w http.ResponseWriter, r *http.Request, n *routeNode) {
+	if rt.MethodNotAllowed != nil {
+		rt.MethodNotAllowed.ServeHTTP(w, r)
+		return
+	}
+	methods := make([]string, 0, len(n.handlers))
+	for m := range n.handlers {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// lookup finds the node for path, which has its leading slash removed, backtracking from static segments to parameters to wildcards
+func (n *routeNode) lookup(path string, ps Params) (*routeNode, Params) {
+	seg, rest, last := path, "", true
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		seg, rest, last = path[:i], path[i+1:], false
+	}
+
+	if child := n.static[seg]; child != nil {
+		if last {
+			if child.handlers != nil {
+				return child, ps
+			}
+		} else if found, fps := child.lookup(rest, ps); found != nil {
+			return found, fps
+		}
+	}
+	if n.param != nil && seg != "" {
+		ps := append(ps, Param{n.param.name, seg})
+		if last {
+			if n.param.handlers != nil {
+				return n.param, ps
+			}
+		} else if found, fps := n.param.lookup(rest, ps); found != nil {
+			return found, fps
+		}
+	}
+	if n.wildcard != nil {
+		return n.wildcard, append(ps, Param{n.wildcard.name, path})
+	}
+	return nil, ps
+}
+
+// stripPort removes the port from a Host header without allocating
+func stripPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return host
+}
@@ -0,0 +1,162 @@
+package fasturl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter() *Router {
+	rt := NewRouter()
+	for _, route := range []struct{ method, pattern string }{
+		{"GET", "/"},
+		{"GET", "/users"},
+		{"GET", "/users/new"},
+		{"GET", "/users/:id"},
+		{"DELETE", "/users/:id"},
+		{"GET", "/users/:id/files/*path"},
+		{"GET", "/static/*file"},
+		{"", "/any"},
+		{"GET", "api.example.com/users/:id"},
+	} {
+		pattern := route.pattern
+		rt.Handle(route.method, pattern, func(w http.ResponseWriter, r *http.Request, u *URL, ps Params) {
+			w.Header().Set("Route", pattern)
+			for _, p := range ps {
+				w.Header().Add("Param", p.Key+"="+p.Value)
+			}
+			w.Header().Set("Query", u.Query)
+		})
+	}
+	return rt
+}
+
+func TestRouter(t *testing.T) {
+	rt := newTestRouter()
+	for _, tc := range []struct {
+		method, target, route string
+		params                []string
+	}{
+		{"GET", "/", "/", nil},
+		{"GET", "/users", "/users", nil},
+		{"GET", "/users/new", "/users/new", nil},
+		{"GET", "/users/42", "/users/:id", []string{"id=42"}},
+		{"DELETE", "/users/42", "/users/:id", []string{"id=42"}},
+		{"GET", "/users/a%20b?x=1", "/users/:id", []string{"id=a%20b"}},
+		{"GET", "/users/42/files/a/b.txt", "/users/:id/files/*path", []string{"id=42", "path=a/b.txt"}},
+		{"GET", "/users/new/files/x", "/users/:id/files/*path", []string{"id=new", "path=x"}},
+		{"GET", "/static/", "/static/*file", []string{"file="}},
+		{"GET", "/static/css/site.css", "/static/*file", []string{"file=css/site.css"}},
+		{"POST", "/any", "/any", nil},
+		{"GET", "http://other.example.com/users/7", "/users/:id", []string{"id=7"}},
+		{"GET", "http://API.example.com/users/7", "api.example.com/users/:id", []string{"id=7"}},
+		{"GET", "/users/7?next=/home?tab=1", "/users/:id", []string{"id=7"}},
+		{"GET", "http://api.example.com/users/7?u=/y", "api.example.com/users/:id", []string{"id=7"}},
+	} {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, tc.target)
+		assert.Equal(t, tc.route, w.Header().Get("Route"), tc.target)
+		assert.Equal(t, tc.params, w.Header()["Param"], tc.target)
+	}
+
+	t.Run("Host header", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/users/7?q=1", nil)
+		req.Host = "api.example.com:8080"
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+		assert.Equal(t, "api.example.com/users/:id", w.Header().Get("Route"))
+		assert.Equal(t, "q=1", w.Header().Get("Query"))
+	})
+
+	t.Run("Origin-form with an authority", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RequestURI = "//api.example.com/users/7"
+		req.Host = "public.example.com"
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Empty(t, w.Header().Get("Route"), "the host comes from the Host header only")
+	})
+
+	t.Run("Query with slashes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("GET", "/users?next=/home?tab=1", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "next=/home?tab=1", w.Header().Get("Query"))
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		for _, target := range []string{"/users/", "/users/42/files", "/static", "/nope", "/users/42/extra"} {
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+			assert.Equal(t, http.StatusNotFound, w.Code, target)
+		}
+	})
+
+	t.Run("Method not allowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest("PUT", "/users/42", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "DELETE, GET", w.Header().Get("Allow"))
+	})
+
+	t.Run("Bad request", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		for _, target := range []string{"*", "example.com:443", "/a?b#c", "/a#frag", "http://x/a#frag"} {
+			req.RequestURI = target
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		}
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		noop := func(http.ResponseWriter, *http.Request, *URL, Params) {}
+		assert.Panics(t, func() { rt.Handle("GET", "/users/:name", noop) })
+		assert.Panics(t, func() { rt.Handle("GET", "/users", noop) })
+		assert.Panics(t, func() { rt.Handle("GET", "/static/*path", noop) })
+		assert.Panics(t, func() { rt.Handle("GET", "/files/*path/x", noop) })
+		assert.Panics(t, func() { rt.Handle("GET", "example.com", noop) })
+	})
+}
+
+// nopResponseWriter discards everything so allocations of the recorder don't count against the router
+type nopResponseWriter struct{ header http.Header }
+
+func (w nopResponseWriter) Header() http.Header       { return w.header }
+func (nopResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (nopResponseWriter) WriteHeader(int)             {}
+
+func TestRouterAllocs(t *testing.T) {
+	rt := NewRouter()
+	var got string
+	rt.Handle("GET", "/users/:id/files/*path", func(w http.ResponseWriter, r *http.Request, u *URL, ps Params) {
+		got = ps.Get("path")
+	})
+	req := httptest.NewRequest("GET", "/users/42/files/a/b.txt?download=1", nil)
+	w := nopResponseWriter{http.Header{}}
+
+	allocs := testing.AllocsPerRun(1000, func() { rt.ServeHTTP(w, req) })
+	require.Equal(t, "a/b.txt", got)
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkRouter(b *testing.B) {
+	rt := NewRouter()
+	noop := func(http.ResponseWriter, *http.Request, *URL, Params) {}
+	for _, pattern := range []string{"/", "/users", "/users/new", "/users/:id", "/users/:id/files/*path", "/static/*file"} {
+		rt.Handle("GET", pattern, noop)
+	}
+	req := httptest.NewRequest("GET", "/users/42/files/a/b.txt?download=1", nil)
+	w := nopResponseWriter{http.Header{}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rt.ServeHTTP(w, req)
+	}
+}
@@ -0,0 +1,320 @@
+// Package sitemap streams the entries of sitemaps and sitemap indexes following the sitemaps.org protocol,
+// with the Google image and video extensions, validating every location against the sitemap's own URL.
+package sitemap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ImVexed/fasturl"
+)
+
+// Protocol limits of a single sitemap file
+const (
+	MaxEntries = 50000
+	MaxSize    = 50 << 20
+	MaxLocSize = 2048
+)
+
+// Errors flagging an invalid `Entry`, entries with other problems wrap `ErrInvalidEntry`
+var (
+	ErrInvalidEntry = errors.New("sitemap: invalid entry")
+	ErrInvalidLoc   = fmt.Errorf("%w: invalid loc", ErrInvalidEntry)
+	ErrCrossHost    = fmt.Errorf("%w: loc on another host", ErrInvalidEntry)
+	ErrOutOfScope   = fmt.Errorf("%w: loc outside of the sitemap directory", ErrInvalidEntry)
+)
+
+// Errors stopping `Parser.Parse`
+var (
+	ErrNotSitemap     = errors.New("sitemap: root element is neither urlset nor sitemapindex")
+	ErrTooLarge       = errors.New("sitemap: file exceeds the maximum size")
+	ErrTooManyEntries = errors.New("sitemap: file exceeds the maximum number of entries")
+)
+
+// Entry is a <url> of a sitemap or a <sitemap> of a sitemap index
+type Entry struct {
+	// Loc is the location as written, without surrounding whitespace
+	Loc string
+	// URL is Loc parsed by fasturl, nil when it doesn't parse
+	URL *fasturl.URL
+	// Sitemap is set for the entries of a sitemap index, whose locations are other sitemaps
+	Sitemap bool
+	// LastMod is zero when the entry has none
+	LastMod time.Time
+	// ChangeFreq is one of "always", "hourly", "daily", "weekly", "monthly", "yearly" and "never", or empty
+	ChangeFreq string
+	// Priority ranges from 0 to 1, defaults to 0.5
+	Priority float64
+	Images   []Image
+	Videos   []Video
+	// Err is the first problem found in the entry, wrapping `ErrInvalidEntry`, or nil for a valid entry
+	Err error
+}
+
+// Image is an <image:image> of the Google image extension
+type Image struct {
+	Loc string
+	// URL is Loc parsed by fasturl, nil when it doesn't parse. Images may be hosted anywhere.
+	URL     *fasturl.URL
+	Title   string
+	Caption string
+}
+
+// Video is a <video:video> of the Google video extension
+type Video struct {
+	ThumbnailLoc string
+	Title        string
+	Description  string
+	ContentLoc   string
+	PlayerLoc    string
+	// Duration is zero when the video has none
+	Duration time.Duration
+}
+
+type xmlEntry struct {
+	Loc        string     `xml:"loc"`
+	LastMod    string     `xml:"lastmod"`
+	ChangeFreq string     `xml:"changefreq"`
+	Priority   string     `xml:"priority"`
+	Images     []xmlImage `xml:"image"`
+	Videos     []xmlVideo `xml:"video"`
+}
+
+type xmlImage struct {
+	Loc     string `xml:"loc"`
+	Title   string `xml:"title"`
+	Caption string `xml:"caption"`
+}
+
+type xmlVideo struct {
+	ThumbnailLoc string `xml:"thumbnail_loc"`
+	Title        string `xml:"title"`
+	Description  string `xml:"description"`
+	ContentLoc   string `xml:"content_loc"`
+	PlayerLoc    string `xml:"player_loc"`
+	Duration     string `xml:"duration"`
+}
+
+// Parser reads sitemaps and sitemap indexes, plain or gzip compressed, the zero value is ready to use
+type Parser struct {
+	// Location is the URL the sitemap was fetched from. Entries on another scheme, host or port are flagged with
+	// `ErrCrossHost`, and sitemap entries outside of its directory with `ErrOutOfScope`, while a sitemap index may
+	// list sitemaps anywhere on its host. When nil entries aren't checked.
+	Location *fasturl.URL
+	// MaxEntries stops parsing with `ErrTooManyEntries`, defaults to the protocol limit
+	MaxEntries int
+	// MaxSize stops parsing with `ErrTooLarge` once that many uncompressed bytes are read, defaults to the protocol limit
+	MaxSize int64
+}
+
+// Parse streams the entries of r to fn, invalid entries included with their `Entry.Err` set.
+// Parsing stops when the XML is malformed, a limit is exceeded or fn returns an error, and that error is returned.
+func (p *Parser) Parse(r io.Reader, fn func(Entry) error) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	maxSize := p.MaxSize
+	if maxSize <= 0 {
+		maxSize = MaxSize
+	}
+	maxEntries := p.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = MaxEntries
+	}
+
+	// one byte more than the limit tells a file of exactly the maximum size from a larger one
+	dec := xml.NewDecoder(&limitedReader{r, maxSize + 1})
+	entryName, entries := "", 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if entryName == "" {
+				return ErrNotSitemap
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if entryName == "" {
+			switch se.Name.Local {
+			case "urlset":
+				entryName = "url"
+			case "sitemapindex":
+				entryName = "sitemap"
+			default:
+				return ErrNotSitemap
+			}
+			continue
+		}
+		if se.Name.Local != entryName {
+			if err := dec.Skip(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if entries++; entries > maxEntries {
+			return ErrTooManyEntries
+		}
+		var raw xmlEntry
+		if err := dec.DecodeElement(&raw, &se); err != nil {
+			return err
+		}
+		if err := fn(p.entry(&raw, entryName == "sitemap")); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Parser) entry(raw *xmlEntry, sitemap bool) Entry {
+	e := Entry{Loc: strings.TrimSpace(raw.Loc), Sitemap: sitemap, Priority: 0.5}
+	fail := func(err error) {
+		if e.Err == nil {
+			e.Err = err
+		}
+	}
+
+	u, err := fasturl.ParseURLSplit(e.Loc)
+	switch {
+	case e.Loc == "":
+		fail(fmt.Errorf("%w: missing", ErrInvalidLoc))
+	case len(e.Loc) > MaxLocSize:
+		fail(fmt.Errorf("%w: longer than %d characters", ErrInvalidLoc, MaxLocSize))
+	case err != nil:
+		fail(fmt.Errorf("%w: %q doesn't parse", ErrInvalidLoc, e.Loc))
+	case u.Protocol == "" || u.Host == "":
+		e.URL = u
+		fail(fmt.Errorf("%w: %q isn't absolute", ErrInvalidLoc, e.Loc))
+	default:
+		e.URL = u
+		if p.Location != nil {
+			fail(p.checkScope(u, sitemap))
+		}
+	}
+
+	if s := strings.TrimSpace(raw.LastMod); s != "" {
+		if e.LastMod, err = parseW3CDatetime(s); err != nil {
+			fail(fmt.Errorf("%w: invalid lastmod %q", ErrInvalidEntry, s))
+		}
+	}
+	if s := strings.ToLower(strings.TrimSpace(raw.ChangeFreq)); s != "" {
+		switch s {
+		case "always", "hourly", "daily", "weekly", "monthly", "yearly", "never":
+			e.ChangeFreq = s
+		default:
+			fail(fmt.Errorf("%w: invalid changefreq %q", ErrInvalidEntry, s))
+		}
+	}
+	if s := strings.TrimSpace(raw.Priority); s != "" {
+		if pr, err := strconv.ParseFloat(s, 64); err == nil && pr >= 0 && pr <= 1 {
+			e.Priority = pr
+		} else {
+			fail(fmt.Errorf("%w: invalid priority %q", ErrInvalidEntry, s))
+		}
+	}
+
+	for _, img := range raw.Images {
+		i := Image{Loc: strings.TrimSpace(img.Loc), Title: strings.TrimSpace(img.Title), Caption: strings.TrimSpace(img.Caption)}
+		if u, err := fasturl.ParseURLSplit(i.Loc); err == nil && u.Protocol != "" && u.Host != "" {
+			i.URL = u
+		} else {
+			fail(fmt.Errorf("%w: invalid image loc %q", ErrInvalidEntry, i.Loc))
+		}
+		e.Images = append(e.Images, i)
+	}
+	for _, vid := range raw.Videos {
+		v := Video{
+			ThumbnailLoc: strings.TrimSpace(vid.ThumbnailLoc),
+			Title:        strings.TrimSpace(vid.Title),
+			Description:  strings.TrimSpace(vid.Description),
+			ContentLoc:   strings.TrimSpace(vid.ContentLoc),
+			PlayerLoc:    strings.TrimSpace(vid.PlayerLoc),
+		}
+		if s := strings.TrimSpace(vid.Duration); s != "" {
+			if secs, err := strconv.Atoi(s); err == nil && secs > 0 {
+				v.Duration = time.Duration(secs) * time.Second
+			} else {
+				fail(fmt.Errorf("%w: invalid video duration %q", ErrInvalidEntry, s))
+			}
+		}
+		if v.ContentLoc == "" && v.PlayerLoc == "" {
+			fail(fmt.Errorf("%w: video without content_loc nor player_loc", ErrInvalidEntry))
+		}
+		e.Videos = append(e.Videos, v)
+	}
+	return e
+}
+
+// checkScope checks that u is on the scheme, host and port of the sitemap and, unless it is listed by an index,
+// under its directory
+func (p *Parser) checkScope(u *fasturl.URL, sitemap bool) error {
+	loc, n := fasturl.Normalize(p.Location), fasturl.Normalize(u)
+	if n.Protocol != loc.Protocol || strings.TrimSuffix(n.Host, ".") != strings.TrimSuffix(loc.Host, ".") || n.Port != loc.Port {
+		return fmt.Errorf("%w: %q", ErrCrossHost, u.String())
+	}
+	dir := loc.Path[:strings.LastIndexByte(loc.Path, '/')+1]
+	if !sitemap && !strings.HasPrefix(n.Path, dir) {
+		return fmt.Errorf("%w: %q isn't under %q", ErrOutOfScope, u.String(), dir)
+	}
+	return nil
+}
+
+// w3cLayouts are the formats of the W3C Datetime profile of ISO 8601
+var w3cLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func parseW3CDatetime(s string) (time.Time, error) {
+	var err error
+	for _, layout := range w3cLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// limitedReader fails with `ErrTooLarge` rather than ending early, so a truncated file isn't mistaken for a malformed one
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(b)) > l.n {
+		b = b[:l.n]
+	}
+	n, err := l.r.Read(b)
+	l.n -= int64(n)
+	return n, err
+}
@@ -0,0 +1,201 @@
+package sitemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ImVexed/fasturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSitemap = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+        xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+        xmlns:video="http://www.google.com/schemas/sitemap-video/1.1">
+  <url>
+    <loc>https://example.com/catalog/</loc>
+    <lastmod>2005-01-01</lastmod>
+    <changefreq>monthly</changefreq>
+    <priority>0.8</priority>
+  </url>
+  <url>
+    <loc>
+      https://example.com/catalog/item?id=12&amp;desc=vacation
+    </loc>
+    <lastmod>2004-12-23T18:00:15+00:00</lastmod>
+    <changefreq>Weekly</changefreq>
+    <image:image>
+      <image:loc>https://cdn.example.net/photo.jpg</image:loc>
+      <image:title>Beach</image:title>
+    </image:image>
+    <video:video>
+      <video:thumbnail_loc>https://cdn.example.net/thumb.jpg</video:thumbnail_loc>
+      <video:title>Grilling steaks</video:title>
+      <video:description>Alkis shows you how</video:description>
+      <video:content_loc>https://cdn.example.net/video.mp4</video:content_loc>
+      <video:duration>600</video:duration>
+    </video:video>
+  </url>
+  <url><loc>https://www.example.com/catalog/other</loc></url>
+  <url><loc>http://example.com/catalog/insecure</loc></url>
+  <url><loc>https://example.com/about</loc></url>
+  <url><loc>/catalog/relative</loc></url>
+  <url><loc>https://[::1/catalog/unparsable</loc></url>
+  <url><loc>https://example.com/catalog/bad</loc><priority>2</priority><lastmod>yesterday</lastmod></url>
+  <extra><loc>https://example.com/catalog/ignored</loc></extra>
+</urlset>`
+
+func parseAll(t *testing.T, p *Parser, data []byte) ([]Entry, error) {
+	var entries []Entry
+	err := p.Parse(bytes.NewReader(data), func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+func mustParse(t *testing.T, s string) *fasturl.URL {
+	u, err := fasturl.ParseURL(s)
+	require.NoError(t, err)
+	return u
+}
+
+func TestParse(t *testing.T) {
+	p := &Parser{Location: mustParse(t, "https://example.com/catalog/sitemap.xml")}
+	entries, err := parseAll(t, p, []byte(testSitemap))
+	require.NoError(t, err)
+	require.Len(t, entries, 8)
+
+	e := entries[0]
+	assert.NoError(t, e.Err)
+	assert.Equal(t, "https://example.com/catalog/", e.URL.String())
+	assert.False(t, e.Sitemap)
+	assert.Equal(t, time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC), e.LastMod)
+	assert.Equal(t, "monthly", e.ChangeFreq)
+	assert.Equal(t, 0.8, e.Priority)
+
+	e = entries[1]
+	assert.NoError(t, e.Err)
+	assert.Equal(t, "https://example.com/catalog/item?id=12&desc=vacation", e.Loc)
+	assert.Equal(t, "id=12&desc=vacation", e.URL.Query)
+	assert.Equal(t, time.Date(2004, 12, 23, 18, 0, 15, 0, time.UTC), e.LastMod.UTC())
+	assert.Equal(t, "weekly", e.ChangeFreq)
+	assert.Equal(t, 0.5, e.Priority)
+	require.Len(t, e.Images, 1)
+	assert.Equal(t, "cdn.example.net", e.Images[0].URL.Host)
+	assert.Equal(t, "Beach", e.Images[0].Title)
+	require.Len(t, e.Videos, 1)
+	assert.Equal(t, Video{
+		ThumbnailLoc: "https://cdn.example.net/thumb.jpg",
+		Title:        "Grilling steaks",
+		Description:  "Alkis shows you how",
+		ContentLoc:   "https://cdn.example.net/video.mp4",
+		Duration:     10 * time.Minute,
+	}, e.Videos[0])
+
+	for i, want := range []error{ErrCrossHost, ErrCrossHost, ErrOutOfScope, ErrInvalidLoc, ErrInvalidLoc, ErrInvalidEntry} {
+		e := entries[i+2]
+		assert.True(t, errors.Is(e.Err, want), "%s: %v", e.Loc, e.Err)
+		assert.True(t, errors.Is(e.Err, ErrInvalidEntry), e.Loc)
+	}
+	assert.Contains(t, entries[7].Err.Error(), "lastmod")
+
+	t.Run("Query with a path", func(t *testing.T) {
+		const doc = `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url>
+  <loc>https://example.com/catalog/login?redirect=/catalog/cart?step=2</loc>
+  <image:image xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+    <image:loc>https://cdn.example.net/resize?src=/photo.jpg</image:loc>
+  </image:image>
+</url></urlset>`
+		entries, err := parseAll(t, p, []byte(doc))
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.NoError(t, entries[0].Err)
+		assert.Equal(t, "redirect=/catalog/cart?step=2", entries[0].URL.Query)
+		assert.Equal(t, "src=/photo.jpg", entries[0].Images[0].URL.Query)
+	})
+
+	t.Run("Without location", func(t *testing.T) {
+		entries, err := parseAll(t, &Parser{}, []byte(testSitemap))
+		require.NoError(t, err)
+		for _, e := range entries[2:5] {
+			assert.NoError(t, e.Err, e.Loc)
+		}
+	})
+}
+
+func TestParseIndex(t *testing.T) {
+	const index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc>https://example.com/sitemap1.xml.gz</loc>
+    <lastmod>2004-10-01T18:23:17+00:00</lastmod>
+  </sitemap>
+  <sitemap><loc>https://example.com/other/sitemap2.xml.gz</loc></sitemap>
+  <sitemap><loc>https://example.org/sitemap3.xml.gz</loc></sitemap>
+</sitemapindex>`
+
+	p := &Parser{Location: mustParse(t, "https://example.com/sitemaps/index.xml")}
+	entries, err := parseAll(t, p, []byte(index))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	for _, e := range entries {
+		assert.True(t, e.Sitemap)
+	}
+	assert.NoError(t, entries[0].Err)
+	assert.False(t, entries[0].LastMod.IsZero())
+	// an index may list sitemaps outside of its own directory
+	assert.NoError(t, entries[1].Err)
+	assert.True(t, errors.Is(entries[2].Err, ErrCrossHost))
+}
+
+func TestParseGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(testSitemap))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	entries, err := parseAll(t, &Parser{}, buf.Bytes())
+	require.NoError(t, err)
+	assert.Len(t, entries, 8)
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Run("Not a sitemap", func(t *testing.T) {
+		_, err := parseAll(t, &Parser{}, []byte(`<html><body></body></html>`))
+		assert.Equal(t, ErrNotSitemap, err)
+		_, err = parseAll(t, &Parser{}, nil)
+		assert.Equal(t, ErrNotSitemap, err)
+	})
+
+	t.Run("Malformed", func(t *testing.T) {
+		_, err := parseAll(t, &Parser{}, []byte(`<urlset><url><loc>https://example.com/</url></urlset>`))
+		assert.Error(t, err)
+	})
+
+	t.Run("Limits", func(t *testing.T) {
+		_, err := parseAll(t, &Parser{MaxEntries: 3}, []byte(testSitemap))
+		assert.Equal(t, ErrTooManyEntries, err)
+		_, err = parseAll(t, &Parser{MaxSize: 100}, []byte(testSitemap))
+		assert.Equal(t, ErrTooLarge, err)
+		_, err = parseAll(t, &Parser{MaxSize: int64(len(testSitemap))}, []byte(testSitemap))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Callback", func(t *testing.T) {
+		stop := errors.New("stop")
+		n := 0
+		err := (&Parser{}).Parse(strings.NewReader(testSitemap), func(Entry) error {
+			n++
+			return stop
+		})
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 1, n)
+	})
+}
@@ -0,0 +1,362 @@
+package fasturl
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HostKind classifies the target a host refers to
+type HostKind int
+
+const (
+	HostPublic HostKind = iota
+	HostLoopback
+	HostPrivate
+	HostLinkLocal
+	HostMetadata
+	HostUnspecified
+	HostReserved
+	HostInvalid
+)
+
+var hostKindNames = [...]string{"public", "loopback", "private", "link-local", "metadata", "unspecified", "reserved", "invalid"}
+
+func (k HostKind) String() string {
+	if k < 0 || int(k) >= len(hostKindNames) {
+		return "HostKind(" + strconv.Itoa(int(k)) + ")"
+	}
+	return hostKindNames[k]
+}
+
+func mustCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+var hostKindNets = []struct {
+	kind HostKind
+	nets []*net.IPNet
+}{
+	// metadata first, the AWS and Alibaba endpoints live inside the link-local and shared ranges
+	{HostMetadata, mustCIDRs("169.254.169.254/32", "169.254.170.2/32", "100.100.100.200/32", "fd00:ec2::254/128")},
+	{HostUnspecified, mustCIDRs("0.0.0.0/8", "::/128")},
+	{HostLoopback, mustCIDRs("127.0.0.0/8", "::1/128")},
+	{HostPrivate, mustCIDRs("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7")},
+	{HostLinkLocal, mustCIDRs("169.254.0.0/16", "fe80::/10")},
+	{HostReserved, mustCIDRs("192.0.0.0/24", "192.0.2.0/24", "198.18.0.0/15", "198.51.100.0/24", "203.0.113.0/24",
+		"224.0.0.0/4", "240.0.0.0/4", "2001:db8::/32", "ff00::/8")},
+}
+
+// nat64 is the well-known prefix of RFC 6052 and ipv4Compatible the deprecated one of RFC 4291,
+// the last four bytes of both are an IPv4 address. The 6to4 addresses of RFC 3056 hold one right after their prefix.
+var (
+	nat64          = mustCIDRs("64:ff9b::/96")[0]
+	ipv4Compatible = mustCIDRs("::/96")[0]
+	sixToFour      = mustCIDRs("2002::/16")[0]
+)
+
+var localhostNames = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+}
+
+var metadataNames = map[string]bool{
+	"metadata":                   true,
+	"metadata.google.internal":   true,
+	"metadata.goog":              true,
+	"instance-data":              true,
+	"instance-data.ec2.internal": true,
+}
+
+// ClassifyIP returns the kind of target ip is, IPv4-mapped, IPv4-compatible, NAT64 and 6to4 IPv6 addresses are classified
+// by their IPv4 address
+func ClassifyIP(ip net.IP) HostKind {
+	if ip == nil {
+		return HostInvalid
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	} else if nat64.Contains(ip) || ipv4Compatible.Contains(ip) && !ip.Equal(net.IPv6unspecified) && !ip.Equal(net.IPv6loopback) {
+		ip = ip[12:16]
+	} else if sixToFour.Contains(ip) {
+		ip = ip[2:6]
+	}
+	for _, k := range hostKindNets {
+		for _, n := range k.nets {
+			if n.Contains(ip) {
+				return k.kind
+			}
+		}
+	}
+	return HostPublic
+}
+
+// ClassifyHost returns the kind of target host is along with its address when it is an IP literal.
+// IPv4 addresses are read the way browsers do, so "2130706433", "0x7f.1" and "0177.0.0.1" are all loopback.
+func ClassifyHost(host string) (HostKind, net.IP) {
+	if h, err := url.PathUnescape(host); err == nil {
+		host = h
+	} else {
+		return HostInvalid, nil
+	}
+	host = strings.TrimRight(strings.ToLower(host), ".")
+	if host == "" {
+		return HostInvalid, nil
+	}
+
+	if host[0] == '[' {
+		if host[len(host)-1] != ']' {
+			return HostInvalid, nil
+		}
+		host = host[1 : len(host)-1]
+		if i := strings.IndexByte(host, '%'); i >= 0 {
+			host = host[:i]
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return HostInvalid, nil
+		}
+		return ClassifyIP(ip), ip
+	}
+
+	if hasForbiddenHostByte(host) {
+		return HostInvalid, nil
+	}
+	if endsInNumber(host) {
+		ip := parseIPv4(host)
+		if ip == nil {
+			return HostInvalid, nil
+		}
+		return ClassifyIP(ip), ip
+	}
+
+	switch {
+	case localhostNames[host] || strings.HasSuffix(host, ".localhost"):
+		return HostLoopback, nil
+	case metadataNames[host]:
+		return HostMetadata, nil
+	}
+	return HostPublic, nil
+}
+
+// hasForbiddenHostByte reports whether a decoded host name holds a control character or a code point the WHATWG URL
+// standard forbids in hosts, which a resolver may drop or stop at
+func hasForbiddenHostByte(host string) bool {
+	for i := 0; i < len(host); i++ {
+		if c := host[i]; c < 0x20 || c == 0x7f || strings.IndexByte(forbiddenHostCodePoints, c) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// endsInNumber reports whether the last label of host is numeric, in which case browsers parse it as an IPv4 address
+func endsInNumber(host string) bool {
+	last := host[strings.LastIndexByte(host, '.')+1:]
+	if last == "" {
+		return false
+	}
+	if strings.HasPrefix(last, "0x") {
+		for i := 2; i < len(last); i++ {
+			if !isHex(last[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i < len(last); i++ {
+		if last[i] < '0' || last[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIPv4 implements the IPv4 parser of the WHATWG URL standard, accepting one to four decimal, octal or hex parts
+func parseIPv4(host string) net.IP {
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return nil
+	}
+
+	var nums [4]uint64
+	for i, part := range parts {
+		base := 10
+		switch {
+		case strings.HasPrefix(part, "0x"):
+			part, base = part[2:], 16
+			if part == "" {
+				part = "0"
+			}
+		case len(part) > 1 && part[0] == '0':
+			part, base = part[1:], 8
+		case part == "":
+			return nil
+		}
+		n, err := strconv.ParseUint(part, base, 32)
+		if err != nil {
+			return nil
+		}
+		if i < len(parts)-1 && n > 255 {
+			return nil
+		}
+		nums[i] = n
+	}
+
+	last := len(parts) - 1
+	if nums[last] >= 1<<(8*uint(4-last)) {
+		return nil
+	}
+	ipv4 := nums[last]
+	for i := 0; i < last; i++ {
+		ipv4 += nums[i] << (8 * uint(3-i))
+	}
+	return net.IPv4(byte(ipv4>>24), byte(ipv4>>16), byte(ipv4>>8), byte(ipv4)).To4()
+}
+
+// DenyReason explains why an `SSRFPolicy` rejected a URL, `NotDenied` means it didn't
+type DenyReason int
+
+const (
+	NotDenied DenyReason = iota
+	DeniedMalformed
+	DeniedScheme
+	DeniedPort
+	DeniedUserinfo
+	DeniedLoopback
+	DeniedPrivate
+	DeniedLinkLocal
+	DeniedMetadata
+	DeniedUnspecified
+	DeniedReserved
+	DeniedCIDR
+)
+
+var reasonNames = [...]string{"allowed", "malformed", "scheme", "port", "userinfo", "loopback", "private", "link-local", "metadata", "unspecified", "reserved", "cidr"}
+
+func (r DenyReason) String() string {
+	if r < 0 || int(r) >= len(reasonNames) {
+		return "DenyReason(" + strconv.Itoa(int(r)) + ")"
+	}
+	return reasonNames[r]
+}
+
+var hostKindReasons = map[HostKind]DenyReason{
+	HostLoopback:    DeniedLoopback,
+	HostPrivate:     DeniedPrivate,
+	HostLinkLocal:   DeniedLinkLocal,
+	HostMetadata:    DeniedMetadata,
+	HostUnspecified: DeniedUnspecified,
+	HostReserved:    DeniedReserved,
+	HostInvalid:     DeniedMalformed,
+}
+
+// SSRFPolicy decides whether a user supplied URL may be fetched by the server.
+// Host names are only classified by their spelling, dial through `CheckIP` as well to catch names that resolve to internal addresses.
+type SSRFPolicy struct {
+	// Schemes are the allowed schemes, defaults to http and https
+	Schemes []string
+	// Ports are the allowed explicit ports, defaults to any port
+	Ports []int
+	// DenyCIDRs are rejected on top of the internal ranges
+	DenyCIDRs []*net.IPNet
+	// Allow lists host kinds that are accepted, such as `HostPrivate` for a service on the same network
+	Allow []HostKind
+	// AllowUserinfo accepts URLs carrying credentials
+	AllowUserinfo bool
+}
+
+// CheckString parses raw with `ParseURL` and checks it
+func (p *SSRFPolicy) CheckString(raw string) DenyReason {
+	u, err := ParseURL(raw)
+	if err != nil {
+		return DeniedMalformed
+	}
+	return p.Check(u)
+}
+
+// Check returns why u is rejected, or `NotDenied`
+func (p *SSRFPolicy) Check(u *URL) DenyReason {
+	schemes := p.Schemes
+	if schemes == nil {
+		schemes = []string{"http", "https"}
+	}
+	if !containsFold(schemes, u.Protocol) {
+		return DeniedScheme
+	}
+	if u.User != "" && !p.AllowUserinfo {
+		return DeniedUserinfo
+	}
+	if u.Port != "" {
+		n, err := strconv.Atoi(u.Port)
+		if err != nil || n < 0 || n > 65535 {
+			return DeniedMalformed
+		}
+		if p.Ports != nil && !containsInt(p.Ports, n) {
+			return DeniedPort
+		}
+	}
+
+	kind, ip := ClassifyHost(u.Host)
+	if r := p.checkKind(kind); r != NotDenied {
+		return r
+	}
+	if ip != nil {
+		return p.CheckIP(ip)
+	}
+	return NotDenied
+}
+
+// CheckIP checks an address the host of an allowed URL resolved to, for use in `net.Dialer.Control`
+func (p *SSRFPolicy) CheckIP(ip net.IP) DenyReason {
+	if r := p.checkKind(ClassifyIP(ip)); r != NotDenied {
+		return r
+	}
+	for _, n := range p.DenyCIDRs {
+		if n.Contains(ip) {
+			return DeniedCIDR
+		}
+	}
+	return NotDenied
+}
+
+func (p *SSRFPolicy) checkKind(kind HostKind) DenyReason {
+	if kind == HostPublic {
+		return NotDenied
+	}
+	for _, k := range p.Allow {
+		if k == kind && kind != HostInvalid {
+			return NotDenied
+		}
+	}
+	return hostKindReasons[kind]
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package fasturl
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// TargetForm is one of the request-target forms of RFC 9112 section 3.2
+type TargetForm int
+
+const (
+	// OriginForm is an absolute path with an optional query, as in "/where?q=now"
+	OriginForm TargetForm = iota + 1
+	// AbsoluteForm is an absolute URI, as sent to proxies: "http://www.example.org/pub/WWW/"
+	AbsoluteForm
+	// AuthorityForm is the "host:port" of a CONNECT request
+	AuthorityForm
+	// AsteriskForm is the "*" of a server-wide OPTIONS request
+	AsteriskForm
+)
+
+var targetFormNames = [...]string{"", "origin-form", "absolute-form", "authority-form", "asterisk-form"}
+
+func (f TargetForm) String() string {
+	if f <= 0 || int(f) >= len(targetFormNames) {
+		return "TargetForm(" + strconv.Itoa(int(f)) + ")"
+	}
+	return targetFormNames[f]
+}
+
+// Target is a parsed HTTP request-target
+type Target struct {
+	Form TargetForm
+	// URL holds the path and query of an origin-form target, the host and port of an authority-form target
+	// and the path "*" of an asterisk-form target
+	URL *URL
+}
+
+// AllowedFor reports whether the target may be sent with method: authority-form only and always for CONNECT,
+// asterisk-form only for OPTIONS and origin-form or absolute-form for everything else
+func (t Target) AllowedFor(method string) bool {
+	switch t.Form {
+	case AuthorityForm:
+		return method == http.MethodConnect
+	case AsteriskForm:
+		return method == http.MethodOptions
+	case OriginForm, AbsoluteForm:
+		return method != http.MethodConnect
+	}
+	return false
+}
+
+var errInvalidTarget = errors.New("fasturl: invalid request target")
+
+// ParseRequestTarget parses the request-target of an HTTP request line and tells which of its four forms it has.
+// Fragments, whitespace, control and non-ASCII characters are rejected, as are absolute URIs without an authority
+// and authority-form targets with anything but a host and a port.
+func ParseRequestTarget(s string) (Target, error) {
+	if s == "" {
+		return Target{}, errInvalidTarget
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c >= 0x7f || c == '#' {
+			return Target{}, errInvalidTarget
+		}
+	}
+
+	switch {
+	case s == "*":
+		return Target{AsteriskForm, &URL{Path: "*"}}, nil
+	case s[0] == '/':
+		// the parser would read the path "//a" as an authority
+		path, query, _, _ := splitRelativeRef(s)
+		return Target{OriginForm, &URL{Path: path, Query: query}}, nil
+	}
+
+	u := &URL{}
+	if err := parseURLSplit(s, u); err == nil && u.Protocol != "" && !u.opaque {
+		if u.Host == "" {
+			return Target{}, errInvalidTarget
+		}
+		return Target{AbsoluteForm, u}, nil
+	}
+
+	// "example.com:443" reads as the scheme "example.com" to the parser
+	u, err := ParseURL("//" + s)
+	if err != nil || u.Host == "" || u.User != "" || u.Path != "" || strings.IndexByte(s, '?') >= 0 || !isDigits(u.Port) {
+		return Target{}, errInvalidTarget
+	}
+	return Target{AuthorityForm, u}, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,67 @@
+package fasturl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequestTarget(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		form TargetForm
+		want URL
+	}{
+		{"/where?q=now", OriginForm, URL{Path: "/where", Query: "q=now"}},
+		{"/", OriginForm, URL{Path: "/"}},
+		{"//a/b", OriginForm, URL{Path: "//a/b"}},
+		{"/a?b/c?d", OriginForm, URL{Path: "/a", Query: "b/c?d"}},
+		{"http://www.example.org/pub/WWW/TheProject.html", AbsoluteForm, URL{Protocol: "http", Host: "www.example.org", Path: "/pub/WWW/TheProject.html"}},
+		{"http://x/?u=/y", AbsoluteForm, URL{Protocol: "http", Host: "x", Path: "/", Query: "u=/y"}},
+		{"http://example.com?next=/a?b", AbsoluteForm, URL{Protocol: "http", Host: "example.com", Query: "next=/a?b"}},
+		{"https://example.com:8443", AbsoluteForm, URL{Protocol: "https", Host: "example.com", Port: "8443"}},
+		{"www.example.com:80", AuthorityForm, URL{Host: "www.example.com", Port: "80"}},
+		{"localhost:8080", AuthorityForm, URL{Host: "localhost", Port: "8080"}},
+		{"192.0.2.1:443", AuthorityForm, URL{Host: "192.0.2.1", Port: "443"}},
+		{"[2001:db8::1]:443", AuthorityForm, URL{Host: "[2001:db8::1]", Port: "443"}},
+		{"*", AsteriskForm, URL{Path: "*"}},
+	} {
+		target, err := ParseRequestTarget(tc.in)
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.form, target.Form, tc.in)
+		assert.Equal(t, tc.want, *target.URL, tc.in)
+	}
+
+	for _, in := range []string{
+		"", "/a#b", "http://example.com/#b", "/a b", "/a\tb", "/\x7f", "/caf\xc3\xa9",
+		"example.com", "example.com:", "example.com:http", "user@example.com:443", "example.com:443/x", "example.com:443?x",
+		"mailto:user@example.com", "http:///path", "**", "?q",
+	} {
+		_, err := ParseRequestTarget(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestTargetAllowedFor(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		allowed []string
+		denied  []string
+	}{
+		{"/index.html", []string{"GET", "POST", "OPTIONS"}, []string{"CONNECT"}},
+		{"http://example.com/", []string{"GET", "DELETE"}, []string{"CONNECT"}},
+		{"example.com:443", []string{"CONNECT"}, []string{"GET", "OPTIONS"}},
+		{"*", []string{"OPTIONS"}, []string{"GET", "CONNECT"}},
+	} {
+		target, err := ParseRequestTarget(tc.in)
+		require.NoError(t, err, tc.in)
+		for _, m := range tc.allowed {
+			assert.True(t, target.AllowedFor(m), tc.in+" "+m)
+		}
+		for _, m := range tc.denied {
+			assert.False(t, target.AllowedFor(m), tc.in+" "+m)
+		}
+	}
+	assert.Equal(t, "authority-form", AuthorityForm.String())
+}
@@ -0,0 +1,170 @@
+package fasturl
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// trieHostEnd separates the host labels of a trie key from its path segments, neither can contain a "/"
+const trieHostEnd = "/"
+
+// TrieEntry is a prefix stored in a `Trie` with its value
+type TrieEntry struct {
+	Prefix *URL
+	Value  interface{}
+}
+
+// Trie indexes URL prefixes by their reversed host labels and then their path segments, ignoring the scheme,
+// port, query and fragment. A prefix without a path, such as "example.com", covers the host, its subdomains
+// and everything below them. A prefix with a path, even "/", covers only its exact host and the paths below it,
+// so "example.com/docs" covers "example.com/docs/a" but not "www.example.com/docs" nor "example.com/docsx".
+// Hosts and paths are compared in their `Normalize` form, and a trailing slash doesn't change a prefix.
+// It isn't safe for concurrent use while prefixes are being inserted.
+type Trie struct {
+	root trieNode
+	size int
+}
+
+type trieNode struct {
+	children map[string]*trieNode
+	entry    *TrieEntry
+}
+
+// NewTrie returns an empty `Trie`
+func NewTrie() *Trie {
+	return &Trie{}
+}
+
+// Len returns the number of prefixes in the trie
+func (t *Trie) Len() int {
+	return t.size
+}
+
+// Insert stores value under prefix and reports whether the prefix is new, otherwise its value is replaced
+func (t *Trie) Insert(prefix *URL, value interface{}) bool {
+	n := &t.root
+	for _, k := range trieKey(prefix, false) {
+		child := n.children[k]
+		if child == nil {
+			if n.children == nil {
+				n.children = map[string]*trieNode{}
+			}
+			child = &trieNode{}
+			n.children[k] = child
+		}
+		n = child
+	}
+	added := n.entry == nil
+	if added {
+		t.size++
+	}
+	n.entry = &TrieEntry{Prefix: prefix, Value: value}
+	return added
+}
+
+// Get returns the value stored under exactly prefix
+func (t *Trie) Get(prefix *URL) (interface{}, bool) {
+	n := t.find(trieKey(prefix, false))
+	if n == nil || n.entry == nil {
+		return nil, false
+	}
+	return n.entry.Value, true
+}
+
+// LongestPrefix returns the most specific prefix covering u
+func (t *Trie) LongestPrefix(u *URL) (TrieEntry, bool) {
+	var longest *TrieEntry
+	t.covering(u, func(e *TrieEntry) { longest = e })
+	if longest == nil {
+		return TrieEntry{}, false
+	}
+	return *longest, true
+}
+
+// Covering returns every prefix covering u, from the least to the most specific
+func (t *Trie) Covering(u *URL) []TrieEntry {
+	var entries []TrieEntry
+	t.covering(u, func(e *TrieEntry) { entries = append(entries, *e) })
+	return entries
+}
+
+func (t *Trie) covering(u *URL, fn func(*TrieEntry)) {
+	n := &t.root
+	for _, k := range trieKey(u, true) {
+		if n.entry != nil {
+			fn(n.entry)
+		}
+		if n = n.children[k]; n == nil {
+			return
+		}
+	}
+	if n.entry != nil {
+		fn(n.entry)
+	}
+}
+
+// Walk calls fn for every prefix under prefix, itself included, in lexical order of their keys until fn returns false.
+// A nil prefix walks the whole trie.
+func (t *Trie) Walk(prefix *URL, fn func(TrieEntry) bool) {
+	n := &t.root
+	if prefix != nil {
+		n = t.find(trieKey(prefix, false))
+	}
+	if n != nil {
+		n.walk(fn)
+	}
+}
+
+func (n *trieNode) walk(fn func(TrieEntry) bool) bool {
+	if n.entry != nil && !fn(*n.entry) {
+		return false
+	}
+	keys := make([]string, 0, len(n.children))
+	for k := range n.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !n.children[k].walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Trie) find(key []string) *trieNode {
+	n := &t.root
+	for _, k := range key {
+		if n = n.children[k]; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// trieKey splits u into its reversed host labels, `trieHostEnd` and its path segments.
+// The path part is left out of prefixes without a path, while a URL being looked up always has one.
+func trieKey(u *URL, lookup bool) []string {
+	n := Normalize(u)
+	var key []string
+	if host := strings.TrimSuffix(n.Host, "."); host != "" {
+		if strings.HasPrefix(host, "[") || net.ParseIP(host) != nil {
+			// the labels of an address aren't a hierarchy
+			key = append(key, host)
+		} else {
+			labels := strings.Split(host, ".")
+			for i := len(labels) - 1; i >= 0; i-- {
+				key = append(key, labels[i])
+			}
+		}
+	}
+	if u.Path == "" && !lookup {
+		return key
+	}
+	key = append(key, trieHostEnd)
+	if path := strings.Trim(n.Path, "/"); path != "" {
+		key = append(key, strings.Split(path, "/")...)
+	}
+	return key
+}
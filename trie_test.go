@@ -0,0 +1,105 @@
+package fasturl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTrie(t *testing.T, prefixes ...string) *Trie {
+	tr := NewTrie()
+	for _, p := range prefixes {
+		require.True(t, tr.Insert(mustParse(t, p), p), p)
+	}
+	return tr
+}
+
+func TestTrie(t *testing.T) {
+	tr := newTestTrie(t,
+		"example.com",
+		"https://example.com/",
+		"https://example.com/docs/",
+		"https://example.com/docs/api",
+		"https://www.example.com/blog",
+		"https://10.0.0.1/admin",
+	)
+	assert.Equal(t, 6, tr.Len())
+
+	t.Run("Insert", func(t *testing.T) {
+		assert.False(t, tr.Insert(mustParse(t, "http://EXAMPLE.com/docs"), "replaced"))
+		v, ok := tr.Get(mustParse(t, "https://example.com/docs/"))
+		assert.True(t, ok)
+		assert.Equal(t, "replaced", v)
+		tr.Insert(mustParse(t, "https://example.com/docs/"), "https://example.com/docs/")
+		assert.Equal(t, 6, tr.Len())
+
+		_, ok = tr.Get(mustParse(t, "https://example.com/docs/a"))
+		assert.False(t, ok)
+	})
+
+	t.Run("LongestPrefix", func(t *testing.T) {
+		for _, tc := range []struct {
+			url, prefix string
+		}{
+			{"https://example.com/docs/api/v1?x=1", "https://example.com/docs/api"},
+			{"http://example.com:8080/docs/%61pi", "https://example.com/docs/api"},
+			{"https://example.com/docs/./guide", "https://example.com/docs/"},
+			{"https://example.com/docsx", "https://example.com/"},
+			{"https://example.com", "https://example.com/"},
+			{"https://www.example.com/docs/api", "example.com"},
+			{"https://www.example.com/blog/post", "https://www.example.com/blog"},
+			{"https://cdn.www.example.com.", "example.com"},
+			{"https://10.0.0.1/admin/users", "https://10.0.0.1/admin"},
+			{"https://0.0.1/admin", ""},
+			{"https://example.org/docs", ""},
+		} {
+			e, ok := tr.LongestPrefix(mustParse(t, tc.url))
+			if tc.prefix == "" {
+				assert.False(t, ok, tc.url)
+				continue
+			}
+			if assert.True(t, ok, tc.url) {
+				assert.Equal(t, tc.prefix, e.Value, tc.url)
+			}
+		}
+	})
+
+	t.Run("Covering", func(t *testing.T) {
+		var got []interface{}
+		for _, e := range tr.Covering(mustParse(t, "https://example.com/docs/api/v1")) {
+			got = append(got, e.Value)
+		}
+		assert.Equal(t, []interface{}{
+			"example.com",
+			"https://example.com/",
+			"https://example.com/docs/",
+			"https://example.com/docs/api",
+		}, got)
+		assert.Empty(t, tr.Covering(mustParse(t, "https://example.net/")))
+	})
+
+	t.Run("Walk", func(t *testing.T) {
+		walk := func(prefix *URL, limit int) []interface{} {
+			var got []interface{}
+			tr.Walk(prefix, func(e TrieEntry) bool {
+				got = append(got, e.Value)
+				return len(got) < limit
+			})
+			return got
+		}
+		assert.Equal(t, []interface{}{"https://example.com/docs/", "https://example.com/docs/api"},
+			walk(mustParse(t, "https://example.com/docs/"), 10))
+		assert.Equal(t, []interface{}{
+			"example.com",
+			"https://example.com/",
+			"https://example.com/docs/",
+			"https://example.com/docs/api",
+			"https://www.example.com/blog",
+		}, walk(mustParse(t, "example.com"), 10))
+		assert.Equal(t, []interface{}{"https://www.example.com/blog"}, walk(mustParse(t, "www.example.com"), 10))
+		assert.Len(t, walk(nil, 10), 6)
+		assert.Len(t, walk(nil, 2), 2)
+		assert.Empty(t, walk(mustParse(t, "https://example.com/blog"), 10))
+	})
+}
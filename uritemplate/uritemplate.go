@@ -0,0 +1,586 @@
+// Package uritemplate implements RFC 6570 URI Templates such as "/repos{/owner,repo}{?page,per_page}",
+// expanding them into URLs and matching URLs back against them to recover the variables.
+package uritemplate
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ImVexed/fasturl"
+)
+
+// ErrInvalidTemplate is wrapped by every error returned by `New`
+var ErrInvalidTemplate = errors.New("uritemplate: invalid template")
+
+// Values holds the variables of a template. A value is a string, a []string list or an associative array,
+// either a map[string]string, which expands in sorted key order, or a [][2]string, which keeps its order.
+// Other scalars are formatted with fmt.Sprint, nil and empty lists or arrays are undefined.
+type Values map[string]interface{}
+
+// operator describes an expression type, see the table in Appendix A of RFC 6570
+type operator struct {
+	first, sep string
+	named      bool
+	ifemp      string
+	reserved   bool
+	// class is the regexp character class of everything the expansion of the expression can contain
+	class string
+}
+
+const unreservedClass = `A-Za-z0-9\-._~%`
+
+var operators = map[byte]*operator{
+	0:   {first: "", sep: ",", class: unreservedClass + `,=`},
+	'+': {first: "", sep: ",", reserved: true, class: unreservedClass + `:/?#\[\]@!$&'()*+,;=`},
+	'#': {first: "#", sep: ",", reserved: true, class: unreservedClass + `:/?#\[\]@!$&'()*+,;=`},
+	'.': {first: ".", sep: ".", class: unreservedClass + `,=`},
+	'/': {first: "/", sep: "/", class: unreservedClass + `,=/`},
+	';': {first: ";", sep: ";", named: true, class: unreservedClass + `,=;`},
+	'?': {first: "?", sep: "&", named: true, ifemp: "=", class: unreservedClass + `,=&`},
+	'&': {first: "&", sep: "&", named: true, ifemp: "=", class: unreservedClass + `,=&`},
+}
+
+type varspec struct {
+	name    string
+	prefix  int // 0 when there is no prefix modifier
+	explode bool
+}
+
+// part is either a literal or an expression
+type part struct {
+	literal string
+	op      *operator
+	vars    []varspec
+	group   int // capture group of the expression in `Template.re`
+}
+
+// Template is a parsed URI Template, safe for concurrent use
+type Template struct {
+	raw   string
+	parts []part
+	re    *regexp.Regexp
+}
+
+// New parses a URI Template of any level of RFC 6570
+func New(template string) (*Template, error) {
+	t := &Template{raw: template}
+	var re strings.Builder
+	re.WriteString("^")
+	group := 0
+
+	for s := template; s != ""; {
+		i := strings.IndexAny(s, "{}")
+		if i < 0 {
+			i = len(s)
+		}
+		if i > 0 {
+			lit := encode(s[:i], true)
+			t.parts = append(t.parts, part{literal: lit})
+			re.WriteString(regexp.QuoteMeta(lit))
+			s = s[i:]
+			continue
+		}
+		if s[0] == '}' {
+			return nil, fmt.Errorf("%w: unmatched '}' at %d", ErrInvalidTemplate, len(template)-len(s))
+		}
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unclosed expression at %d", ErrInvalidTemplate, len(template)-len(s))
+		}
+		p, err := parseExpression(s[1:end])
+		if err != nil {
+			return nil, err
+		}
+		group++
+		p.group = group
+		t.parts = append(t.parts, p)
+
+		quantifier := "*"
+		if p.op.reserved {
+			quantifier = "*?"
+		}
+		if p.op.first == "" {
+			fmt.Fprintf(&re, "([%s]%s)", p.op.class, quantifier)
+		} else {
+			fmt.Fprintf(&re, "(?:%s([%s]%s))?", regexp.QuoteMeta(p.op.first), p.op.class, quantifier)
+		}
+		s = s[end+1:]
+	}
+	re.WriteString("$")
+
+	var err error
+	if t.re, err = regexp.Compile(re.String()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	return t, nil
+}
+
+// MustNew is like `New` but panics if the template cannot be parsed
+func MustNew(template string) *Template {
+	t, err := New(template)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func parseExpression(expr string) (part, error) {
+	if expr == "" {
+		return part{}, fmt.Errorf("%w: empty expression", ErrInvalidTemplate)
+	}
+	op := operators[0]
+	if o, ok := operators[expr[0]]; ok {
+		op, expr = o, expr[1:]
+	} else if strings.IndexByte("=,!@|", expr[0]) >= 0 {
+		return part{}, fmt.Errorf("%w: reserved operator %q", ErrInvalidTemplate, expr[0])
+	}
+
+	p := part{op: op}
+	for _, spec := range strings.Split(expr, ",") {
+		var v varspec
+		if strings.HasSuffix(spec, "*") {
+			spec, v.explode = spec[:len(spec)-1], true
+		} else if i := strings.IndexByte(spec, ':'); i >= 0 {
+			n, err := strconv.Atoi(spec[i+1:])
+			if err != nil || n < 1 || n > 9999 || spec[i+1] == '+' {
+				return part{}, fmt.Errorf("%w: invalid prefix %q", ErrInvalidTemplate, spec[i+1:])
+			}
+			spec, v.prefix = spec[:i], n
+		}
+		if !validVarname(spec) {
+			return part{}, fmt.Errorf("%w: invalid variable name %q", ErrInvalidTemplate, spec)
+		}
+		v.name = spec
+		p.vars = append(p.vars, v)
+	}
+	return p, nil
+}
+
+func validVarname(name string) bool {
+	if name == "" || name[0] == '.' || name[len(name)-1] == '.' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '_', c == '.':
+		case c == '%' && i+2 < len(name) && isHex(name[i+1]) && isHex(name[i+2]):
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the template as it was given to `New`
+func (t *Template) String() string {
+	return t.raw
+}
+
+// Varnames returns the names of the variables of the template in order of appearance
+func (t *Template) Varnames() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, p := range t.parts {
+		for _, v := range p.vars {
+			if !seen[v.name] {
+				seen[v.name] = true
+				names = append(names, v.name)
+			}
+		}
+	}
+	return names
+}
+
+// Expand substitutes vars into the template, an error is returned for values of unsupported types
+// and for prefix modifiers applied to lists or associative arrays
+func (t *Template) Expand(vars Values) (string, error) {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.op == nil {
+			b.WriteString(p.literal)
+			continue
+		}
+		if err := expandExpression(&b, p, vars); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// ExpandURL expands the template and parses the result with `fasturl.ParseURLSplit`, so that reserved expansions
+// such as "{+path}" may leave a "/" or "?" in the query
+func (t *Template) ExpandURL(vars Values) (*fasturl.URL, error) {
+	s, err := t.Expand(vars)
+	if err != nil {
+		return nil, err
+	}
+	return fasturl.ParseURLSplit(s)
+}
+
+func expandExpression(b *strings.Builder, p part, vars Values) error {
+	op := p.op
+	first := true
+	for _, v := range p.vars {
+		val, err := normalizeValue(vars[v.name])
+		if err != nil {
+			return fmt.Errorf("uritemplate: variable %q: %w", v.name, err)
+		}
+		if val == nil {
+			continue
+		}
+		if first {
+			b.WriteString(op.first)
+			first = false
+		} else {
+			b.WriteString(op.sep)
+		}
+
+		switch val := val.(type) {
+		case string:
+			if v.prefix > 0 {
+				val = truncate(val, v.prefix)
+			}
+			writeNamed(b, op, v.name, val, op.named)
+		case []string:
+			if v.prefix > 0 {
+				return fmt.Errorf("uritemplate: prefix modifier on list %q", v.name)
+			}
+			if v.explode {
+				for i, item := range val {
+					if i > 0 {
+						b.WriteString(op.sep)
+					}
+					writeNamed(b, op, v.name, item, op.named)
+				}
+				continue
+			}
+			if op.named {
+				b.WriteString(v.name + "=")
+			}
+			for i, item := range val {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				b.WriteString(encode(item, op.reserved))
+			}
+		case [][2]string:
+			if v.prefix > 0 {
+				return fmt.Errorf("uritemplate: prefix modifier on associative array %q", v.name)
+			}
+			if v.explode {
+				for i, kv := range val {
+					if i > 0 {
+						b.WriteString(op.sep)
+					}
+					if op.named {
+						writeNamed(b, op, kv[0], kv[1], true)
+					} else {
+						b.WriteString(encode(kv[0], op.reserved) + "=" + encode(kv[1], op.reserved))
+					}
+				}
+				continue
+			}
+			if op.named {
+				b.WriteString(v.name + "=")
+			}
+			for i, kv := range val {
+				if i > 0 {
+					b.WriteString(",")
+				}
+				b.WriteString(encode(kv[0], op.reserved) + "," + encode(kv[1], op.reserved))
+			}
+		}
+	}
+	return nil
+}
+
+// writeNamed writes a single value, prefixed by "name=" for the named operators
+func writeNamed(b *strings.Builder, op *operator, name, value string, named bool) {
+	if named {
+		b.WriteString(encode(name, op.reserved))
+		if value == "" {
+			b.WriteString(op.ifemp)
+			return
+		}
+		b.WriteString("=")
+	}
+	b.WriteString(encode(value, op.reserved))
+}
+
+// normalizeValue converts a variable to a string, []string or [][2]string, returning nil when it is undefined
+func normalizeValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	case []string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	case [][2]string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	case map[string]string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([][2]string, len(keys))
+		for i, k := range keys {
+			pairs[i] = [2]string{k, v[k]}
+		}
+		return pairs, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T", v)
+}
+
+// truncate returns the first n characters of s
+func truncate(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// Match extracts the variables of s, which must be an expansion of the template, ok is false when it isn't.
+// Matching is best effort since expansion loses information: a list of a single item comes back as a string,
+// prefix modifiers yield the prefix, associative arrays are only recovered from exploded expressions
+// and when adjacent expressions compete for the same text the earlier one takes as much as it can.
+func (t *Template) Match(s string) (vars Values, ok bool) {
+	m := t.re.FindStringSubmatchIndex(s)
+	if m == nil {
+		return nil, false
+	}
+	vars = Values{}
+	for _, p := range t.parts {
+		if p.op == nil || m[2*p.group] < 0 {
+			continue
+		}
+		if !matchExpression(vars, p, s[m[2*p.group]:m[2*p.group+1]]) {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+// MatchURL matches the string form of u, see `Template.Match`
+func (t *Template) MatchURL(u *fasturl.URL) (Values, bool) {
+	return t.Match(u.String())
+}
+
+func matchExpression(vars Values, p part, body string) bool {
+	items := strings.Split(body, p.op.sep)
+	if p.op.named {
+		return matchNamed(vars, p, items)
+	}
+
+	for i, v := range p.vars {
+		if len(items) == 0 {
+			return true
+		}
+		// a spec takes one item, or when it can't be told apart everything the specs after it leave over
+		n := 1
+		if v.explode || p.op.sep == "," && i == len(p.vars)-1 {
+			n = len(items) - (len(p.vars) - 1 - i)
+		}
+		if n < 1 {
+			n = 1
+		}
+		var val interface{}
+		var ok bool
+		switch {
+		case v.explode:
+			val, ok = decodeExploded(items[:n])
+		case p.op.sep == ",":
+			val, ok = decodeList(items[:n])
+		default:
+			val, ok = decodeList(strings.Split(items[0], ","))
+		}
+		if !ok {
+			return false
+		}
+		vars[v.name] = val
+		items = items[n:]
+	}
+	return len(items) == 0
+}
+
+func matchNamed(vars Values, p part, items []string) bool {
+	var exploded *varspec
+	for i := range p.vars {
+		if p.vars[i].explode {
+			exploded = &p.vars[i]
+			break
+		}
+	}
+
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		key, value := item, ""
+		if i := strings.IndexByte(item, '='); i >= 0 {
+			key, value = item[:i], item[i+1:]
+		}
+		var spec *varspec
+		for i := range p.vars {
+			if p.vars[i].name == key {
+				spec = &p.vars[i]
+				break
+			}
+		}
+
+		switch {
+		case spec != nil && spec.explode:
+			v, err := url.PathUnescape(value)
+			if err != nil {
+				return false
+			}
+			list, _ := vars[key].([]string)
+			vars[key] = append(list, v)
+		case spec != nil:
+			val, ok := decodeList(strings.Split(value, ","))
+			if !ok {
+				return false
+			}
+			vars[key] = val
+		case exploded != nil:
+			k, err := url.PathUnescape(key)
+			if err != nil {
+				return false
+			}
+			v, err := url.PathUnescape(value)
+			if err != nil {
+				return false
+			}
+			m, _ := vars[exploded.name].(map[string]string)
+			if m == nil {
+				m = map[string]string{}
+				vars[exploded.name] = m
+			}
+			m[k] = v
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decodeList returns a single item as a string and more as a []string
+func decodeList(items []string) (interface{}, bool) {
+	list := make([]string, len(items))
+	for i, item := range items {
+		v, err := url.PathUnescape(item)
+		if err != nil {
+			return nil, false
+		}
+		list[i] = v
+	}
+	if len(list) == 1 {
+		return list[0], true
+	}
+	return list, true
+}
+
+// decodeExploded returns items of the form "key=value" as a map[string]string and others as a []string
+func decodeExploded(items []string) (interface{}, bool) {
+	pairs := map[string]string{}
+	for _, item := range items {
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			pairs = nil
+			break
+		}
+		k, err := url.PathUnescape(item[:i])
+		if err != nil {
+			return nil, false
+		}
+		v, err := url.PathUnescape(item[i+1:])
+		if err != nil {
+			return nil, false
+		}
+		pairs[k] = v
+	}
+	if pairs != nil {
+		return pairs, true
+	}
+
+	list := make([]string, len(items))
+	for i, item := range items {
+		v, err := url.PathUnescape(item)
+		if err != nil {
+			return nil, false
+		}
+		list[i] = v
+	}
+	return list, true
+}
+
+const upperhex = "0123456789ABCDEF"
+
+// encode percent-encodes everything but unreserved characters, and with reserved also keeps reserved characters and pct-encoded triplets
+func encode(s string, reserved bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if keep(s, i, reserved) {
+			if b.Len() > 0 {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		if b.Len() == 0 {
+			b.Grow(len(s) + 8)
+			b.WriteString(s[:i])
+		}
+		b.WriteByte('%')
+		b.WriteByte(upperhex[s[i]>>4])
+		b.WriteByte(upperhex[s[i]&15])
+	}
+	if b.Len() == 0 {
+		return s
+	}
+	return b.String()
+}
+
+func keep(s string, i int, reserved bool) bool {
+	c := s[i]
+	switch {
+	case isUnreserved(c):
+		return true
+	case !reserved:
+		return false
+	case c == '%':
+		return i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2])
+	}
+	return strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
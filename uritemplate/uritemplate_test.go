@@ -0,0 +1,281 @@
+package uritemplate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcValues are the variables of the examples in section 3.2 of RFC 6570
+var rfcValues = Values{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       [][2]string{{"semi", ";"}, {"dot", "."}, {"comma", ","}},
+	"v":          "6",
+	"x":          "1024",
+	"y":          "768",
+	"empty":      "",
+	"empty_keys": [][2]string{},
+	"undef":      nil,
+}
+
+func TestExpandRFC(t *testing.T) {
+	for _, tc := range []struct{ template, want string }{
+		// 3.2.1 Variable Expansion
+		{"{count}", "one,two,three"},
+		{"{count*}", "one,two,three"},
+		{"{/count}", "/one,two,three"},
+		{"{/count*}", "/one/two/three"},
+		{"{;count}", ";count=one,two,three"},
+		{"{;count*}", ";count=one;count=two;count=three"},
+		{"{?count}", "?count=one,two,three"},
+		{"{?count*}", "?count=one&count=two&count=three"},
+		{"{&count*}", "&count=one&count=two&count=three"},
+
+		// 3.2.2 Simple String Expansion
+		{"{var}", "value"},
+		{"{hello}", "Hello%20World%21"},
+		{"{half}", "50%25"},
+		{"O{empty}X", "OX"},
+		{"O{undef}X", "OX"},
+		{"{x,y}", "1024,768"},
+		{"{x,hello,y}", "1024,Hello%20World%21,768"},
+		{"?{x,empty}", "?1024,"},
+		{"?{x,undef}", "?1024"},
+		{"?{undef,y}", "?768"},
+		{"{var:3}", "val"},
+		{"{var:30}", "value"},
+		{"{list}", "red,green,blue"},
+		{"{list*}", "red,green,blue"},
+		{"{keys}", "semi,%3B,dot,.,comma,%2C"},
+		{"{keys*}", "semi=%3B,dot=.,comma=%2C"},
+
+		// 3.2.3 Reserved Expansion
+		{"{+var}", "value"},
+		{"{+hello}", "Hello%20World!"},
+		{"{+half}", "50%25"},
+		{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{"{+base}index", "http://example.com/home/index"},
+		{"O{+empty}X", "OX"},
+		{"O{+undef}X", "OX"},
+		{"{+path}/here", "/foo/bar/here"},
+		{"here?ref={+path}", "here?ref=/foo/bar"},
+		{"up{+path}{var}/here", "up/foo/barvalue/here"},
+		{"{+x,hello,y}", "1024,Hello%20World!,768"},
+		{"{+path,x}/here", "/foo/bar,1024/here"},
+		{"{+path:6}/here", "/foo/b/here"},
+		{"{+list}", "red,green,blue"},
+		{"{+list*}", "red,green,blue"},
+		{"{+keys}", "semi,;,dot,.,comma,,"},
+		{"{+keys*}", "semi=;,dot=.,comma=,"},
+
+		// 3.2.4 Fragment Expansion
+		{"{#var}", "#value"},
+		{"{#hello}", "#Hello%20World!"},
+		{"{#half}", "#50%25"},
+		{"foo{#empty}", "foo#"},
+		{"foo{#undef}", "foo"},
+		{"{#x,hello,y}", "#1024,Hello%20World!,768"},
+		{"{#path,x}/here", "#/foo/bar,1024/here"},
+		{"{#path:6}/here", "#/foo/b/here"},
+		{"{#list}", "#red,green,blue"},
+		{"{#list*}", "#red,green,blue"},
+		{"{#keys}", "#semi,;,dot,.,comma,,"},
+		{"{#keys*}", "#semi=;,dot=.,comma=,"},
+
+		// 3.2.5 Label Expansion with Dot-Prefix
+		{"{.who}", ".fred"},
+		{"{.who,who}", ".fred.fred"},
+		{"{.half,who}", ".50%25.fred"},
+		{"www{.dom*}", "www.example.com"},
+		{"X{.var}", "X.value"},
+		{"X{.empty}", "X."},
+		{"X{.undef}", "X"},
+		{"X{.var:3}", "X.val"},
+		{"X{.list}", "X.red,green,blue"},
+		{"X{.list*}", "X.red.green.blue"},
+		{"X{.keys}", "X.semi,%3B,dot,.,comma,%2C"},
+		{"X{.keys*}", "X.semi=%3B.dot=..comma=%2C"},
+		{"X{.empty_keys}", "X"},
+		{"X{.empty_keys*}", "X"},
+
+		// 3.2.6 Path Segment Expansion
+		{"{/who}", "/fred"},
+		{"{/who,who}", "/fred/fred"},
+		{"{/half,who}", "/50%25/fred"},
+		{"{/who,dub}", "/fred/me%2Ftoo"},
+		{"{/var}", "/value"},
+		{"{/var,empty}", "/value/"},
+		{"{/var,undef}", "/value"},
+		{"{/var,x}/here", "/value/1024/here"},
+		{"{/var:1,var}", "/v/value"},
+		{"{/list}", "/red,green,blue"},
+		{"{/list*}", "/red/green/blue"},
+		{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+		{"{/keys}", "/semi,%3B,dot,.,comma,%2C"},
+		{"{/keys*}", "/semi=%3B/dot=./comma=%2C"},
+
+		// 3.2.7 Path-Style Parameter Expansion
+		{"{;who}", ";who=fred"},
+		{"{;half}", ";half=50%25"},
+		{"{;empty}", ";empty"},
+		{"{;v,empty,who}", ";v=6;empty;who=fred"},
+		{"{;v,bar,who}", ";v=6;who=fred"},
+		{"{;x,y}", ";x=1024;y=768"},
+		{"{;x,y,empty}", ";x=1024;y=768;empty"},
+		{"{;x,y,undef}", ";x=1024;y=768"},
+		{"{;hello:5}", ";hello=Hello"},
+		{"{;list}", ";list=red,green,blue"},
+		{"{;list*}", ";list=red;list=green;list=blue"},
+		{"{;keys}", ";keys=semi,%3B,dot,.,comma,%2C"},
+		{"{;keys*}", ";semi=%3B;dot=.;comma=%2C"},
+
+		// 3.2.8 Form-Style Query Expansion
+		{"{?who}", "?who=fred"},
+		{"{?half}", "?half=50%25"},
+		{"{?x,y}", "?x=1024&y=768"},
+		{"{?x,y,empty}", "?x=1024&y=768&empty="},
+		{"{?x,y,undef}", "?x=1024&y=768"},
+		{"{?var:3}", "?var=val"},
+		{"{?list}", "?list=red,green,blue"},
+		{"{?list*}", "?list=red&list=green&list=blue"},
+		{"{?keys}", "?keys=semi,%3B,dot,.,comma,%2C"},
+		{"{?keys*}", "?semi=%3B&dot=.&comma=%2C"},
+
+		// 3.2.9 Form-Style Query Continuation
+		{"{&who}", "&who=fred"},
+		{"{&half}", "&half=50%25"},
+		{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+		{"{&x,y,empty}", "&x=1024&y=768&empty="},
+		{"{&var:3}", "&var=val"},
+		{"{&list}", "&list=red,green,blue"},
+		{"{&list*}", "&list=red&list=green&list=blue"},
+		{"{&keys}", "&keys=semi,%3B,dot,.,comma,%2C"},
+		{"{&keys*}", "&semi=%3B&dot=.&comma=%2C"},
+	} {
+		got, err := MustNew(tc.template).Expand(rfcValues)
+		require.NoError(t, err, tc.template)
+		assert.Equal(t, tc.want, got, tc.template)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Run("Map keys are sorted", func(t *testing.T) {
+		got, err := MustNew("{?params*}").Expand(Values{"params": map[string]string{"b": "2", "a": "1"}})
+		require.NoError(t, err)
+		assert.Equal(t, "?a=1&b=2", got)
+	})
+
+	t.Run("Scalars", func(t *testing.T) {
+		got, err := MustNew("/issues{/id}{?closed}").Expand(Values{"id": 42, "closed": true})
+		require.NoError(t, err)
+		assert.Equal(t, "/issues/42?closed=true", got)
+	})
+
+	t.Run("Unicode", func(t *testing.T) {
+		got, err := MustNew("{/name:2}").Expand(Values{"name": "café"})
+		require.NoError(t, err)
+		assert.Equal(t, "/ca", got)
+		got, err = MustNew("{/name}").Expand(Values{"name": "café"})
+		require.NoError(t, err)
+		assert.Equal(t, "/caf%C3%A9", got)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := MustNew("{list:3}").Expand(Values{"list": []string{"a"}})
+		assert.Error(t, err)
+		_, err = MustNew("{x}").Expand(Values{"x": struct{}{}})
+		assert.Error(t, err)
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		u, err := MustNew("https://api.example.com/repos{/owner,repo}{?page,per_page}").ExpandURL(Values{
+			"owner": "ImVexed", "repo": "fasturl", "page": 2, "per_page": "a/b?c",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "api.example.com", u.Host)
+		assert.Equal(t, "/repos/ImVexed/fasturl", u.Path)
+		assert.Equal(t, "page=2&per_page=a%2Fb%3Fc", u.Query)
+
+		// the example of RFC 6570 section 3.2.3
+		u, err = MustNew("here?ref={+path}").ExpandURL(Values{"path": "/foo/bar"})
+		require.NoError(t, err)
+		assert.Equal(t, "ref=/foo/bar", u.Query)
+
+		u, err = MustNew("/s?next={+path}#{+frag}").ExpandURL(Values{"path": "/a?b=c", "frag": "x/y"})
+		require.NoError(t, err)
+		assert.Equal(t, "/s", u.Path)
+		assert.Equal(t, "next=/a?b=c", u.Query)
+		assert.Equal(t, "x/y", u.Fragment)
+	})
+}
+
+func TestNew(t *testing.T) {
+	for _, template := range []string{
+		"{", "}", "{}", "/foo{bar", "{=var}", "{!var}", "{va r}", "{var:0}", "{var:10000}", "{var:+3}", "{.var.}",
+	} {
+		_, err := New(template)
+		assert.True(t, errors.Is(err, ErrInvalidTemplate), template)
+	}
+
+	tmpl := MustNew("/repos{/owner,repo}{?page,per_page}{&owner}")
+	assert.Equal(t, []string{"owner", "repo", "page", "per_page"}, tmpl.Varnames())
+	assert.Equal(t, "/repos{/owner,repo}{?page,per_page}{&owner}", tmpl.String())
+}
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		template, input string
+		want            Values
+	}{
+		{"/repos{/owner,repo}{?page,per_page}", "/repos/ImVexed/fasturl?page=2", Values{"owner": "ImVexed", "repo": "fasturl", "page": "2"}},
+		{"/repos{/owner,repo}{?page,per_page}", "/repos/ImVexed", Values{"owner": "ImVexed"}},
+		{"/repos{/owner,repo}{?page,per_page}", "/repos", Values{}},
+		{"/search{?q,tags}", "/search?q=hello%20world&tags=a,b", Values{"q": "hello world", "tags": []string{"a", "b"}}},
+		{"/search{?tag*}", "/search?tag=a&tag=b", Values{"tag": []string{"a", "b"}}},
+		{"/search{?params*}", "/search?a=1&b=2", Values{"params": map[string]string{"a": "1", "b": "2"}}},
+		{"/files{/path*}", "/files/a/b/c.txt", Values{"path": []string{"a", "b", "c.txt"}}},
+		{"/files{/path*,name}", "/files/a/b/c.txt", Values{"path": []string{"a", "b"}, "name": "c.txt"}},
+		{"{+base}/{id}", "http://example.com/home/42", Values{"base": "http://example.com/home", "id": "42"}},
+		{"www{.dom*}", "www.example.com", Values{"dom": []string{"example", "com"}}},
+		{"{;x,y}", ";x=1024;y=768", Values{"x": "1024", "y": "768"}},
+		{"/users/{id}{#section}", "/users/7#bio", Values{"id": "7", "section": "bio"}},
+	} {
+		vars, ok := MustNew(tc.template).Match(tc.input)
+		require.True(t, ok, tc.input)
+		assert.Equal(t, tc.want, vars, tc.input)
+	}
+
+	for _, tc := range []struct{ template, input string }{
+		{"/repos{/owner,repo}", "/users/ImVexed"},
+		{"/repos{/owner,repo}", "/repos/a/b/c"},
+		{"/search{?q}", "/search?page=2"},
+		{"/items/{id}", "/items/a/b"},
+		{"/items/{id}{?page}", "/items/1?page=%zz"},
+		{"https://example.com{/p}", "http://example.com/x"},
+	} {
+		_, ok := MustNew(tc.template).Match(tc.input)
+		assert.False(t, ok, tc.input)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tmpl := MustNew("https://api.example.com/repos{/owner,repo}/issues{?state,labels,page}")
+	vars := Values{"owner": "Im Vexed", "repo": "fast/url", "state": "open", "labels": []string{"bug", "help wanted"}, "page": "3"}
+
+	u, err := tmpl.ExpandURL(vars)
+	require.NoError(t, err)
+	got, ok := tmpl.MatchURL(u)
+	require.True(t, ok)
+	assert.Equal(t, vars, got)
+}
@@ -0,0 +1,103 @@
+package fasturl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// String reassembles the URL from its components
+func (u *URL) String() string {
+	var sb strings.Builder
+	sb.Grow(len(u.Protocol) + len(u.User) + len(u.Host) + len(u.Port) + len(u.Path) + len(u.Query) + len(u.Fragment) + 8)
+
+	if u.Protocol != "" {
+		sb.WriteString(u.Protocol)
+		sb.WriteByte(':')
+	}
+	if !u.opaque && (u.Protocol != "" || u.User != "" || u.Host != "" || u.Port != "") {
+		sb.WriteString("//")
+	}
+	if u.User != "" {
+		sb.WriteString(u.User)
+		sb.WriteByte('@')
+	}
+	if u.Host != "" || u.Port != "" {
+		sb.WriteString(u.Host)
+		if u.Port != "" {
+			sb.WriteByte(':')
+			sb.WriteString(u.Port)
+		}
+	}
+	sb.WriteString(u.Path)
+	if u.Query != "" {
+		sb.WriteByte('?')
+		sb.WriteString(u.Query)
+	}
+	if u.Fragment != "" {
+		sb.WriteByte('#')
+		sb.WriteString(u.Fragment)
+	}
+	return sb.String()
+}
+
+// QueryValue returns the decoded value of the first query parameter named key
+func (u *URL) QueryValue(key string) (string, bool) {
+	value, found := "", false
+	eachQueryParam(u.Query, func(k, v string) bool {
+		if dk, err := url.QueryUnescape(k); err != nil || dk != key {
+			return true
+		}
+		value, found = v, true
+		return false
+	})
+	if !found {
+		return "", false
+	}
+	if dv, err := url.QueryUnescape(value); err == nil {
+		value = dv
+	}
+	return value, true
+}
+
+// ParseURLSplit parses a URL like `ParseURL`, but with the query and fragment split off first so that they may hold
+// the "/" and "?" the parser rejects, as in "https://example.com/login?next=/home"
+func ParseURLSplit(data string) (*URL, error) {
+	u := &URL{}
+	if err := parseURLSplit(data, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// parseURLSplit parses data into the zero value u like `parseURL`, but with the query and fragment split off by hand
+// first since the parser rejects the "/" and "?" they may hold
+func parseURLSplit(data string, u *URL) error {
+	rest, query, fragment, _ := splitRelativeRef(data)
+	if err := parseURL(rest, u); err != nil {
+		return err
+	}
+	u.Query, u.Fragment = query, fragment
+	return nil
+}
+
+// eachQueryParam calls fn with the raw key and value of every `&` separated parameter in q until fn returns false
+func eachQueryParam(q string, fn func(key, value string) bool) {
+	for q != "" {
+		param := q
+		if i := strings.IndexByte(q, '&'); i >= 0 {
+			param, q = q[:i], q[i+1:]
+		} else {
+			q = ""
+		}
+		if param == "" {
+			continue
+		}
+		key, value := param, ""
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			key, value = param[:i], param[i+1:]
+		}
+		if !fn(key, value) {
+			return
+		}
+	}
+}
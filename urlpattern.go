@@ -0,0 +1,1293 @@
+package fasturl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// URLPatternInit holds the components of a pattern or of an input as in the URLPatternInit dictionary of the WHATWG URLPattern standard.
+// Keys are "protocol", "username", "password", "hostname", "port", "pathname", "search", "hash" and "baseURL",
+// a missing key is different from an empty value.
+type URLPatternInit map[string]string
+
+// URLPatternOptions configures how a `URLPattern` is compiled
+type URLPatternOptions struct {
+	IgnoreCase bool
+}
+
+// URLPatternComponentResult is the match of a single component, unmatched optional groups are missing from Groups
+type URLPatternComponentResult struct {
+	Input  string
+	Groups map[string]string
+}
+
+// URLPatternResult is the result of a successful `URLPattern.Exec`
+type URLPatternResult struct {
+	Protocol URLPatternComponentResult
+	Username URLPatternComponentResult
+	Password URLPatternComponentResult
+	Hostname URLPatternComponentResult
+	Port     URLPatternComponentResult
+	Pathname URLPatternComponentResult
+	Search   URLPatternComponentResult
+	Hash     URLPatternComponentResult
+}
+
+// URLPattern matches URLs against per-component patterns such as "https://*.example.com/books/:id(\\d+)",
+// following the WHATWG URLPattern standard. Regular expression groups use RE2 syntax, so lookarounds and backreferences are rejected.
+type URLPattern struct {
+	components [8]patternComponent
+}
+
+// patternComponentNames are the components of a URLPattern in the order of `URLPattern.components`
+var patternComponentNames = [8]string{"protocol", "username", "password", "hostname", "port", "pathname", "search", "hash"}
+
+const (
+	compProtocol = iota
+	compUsername
+	compPassword
+	compHostname
+	compPort
+	compPathname
+	compSearch
+	compHash
+)
+
+var errInvalidPattern = errors.New("fasturl: invalid URL pattern")
+
+func patternError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{errInvalidPattern}, args...)...)
+}
+
+// NewURLPattern compiles a pattern string such as "https://example.com/books/:id", relative patterns are resolved against baseURL
+func NewURLPattern(pattern, baseURL string, opts URLPatternOptions) (*URLPattern, error) {
+	init, err := parseConstructorString(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if baseURL == "" {
+		if _, ok := init["protocol"]; !ok {
+			return nil, patternError("relative pattern %q without a base URL", pattern)
+		}
+	} else {
+		init["baseURL"] = baseURL
+	}
+	return NewURLPatternInit(init, opts)
+}
+
+// NewURLPatternInit compiles a pattern given component by component, missing components match anything
+func NewURLPatternInit(init URLPatternInit, opts URLPatternOptions) (*URLPattern, error) {
+	processed, err := processPatternInit(init, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range patternComponentNames {
+		if _, ok := processed[name]; !ok {
+			processed[name] = "*"
+		}
+	}
+	if defaultPorts[processed["protocol"]] == processed["port"] && isSpecialScheme(processed["protocol"]) {
+		processed["port"] = ""
+	}
+
+	p := &URLPattern{}
+	if p.components[compProtocol], err = compileComponent(processed["protocol"], canonicalizeProtocol, patternOptions{}); err != nil {
+		return nil, err
+	}
+	if p.components[compUsername], err = compileComponent(processed["username"], canonicalizeUserinfo, patternOptions{}); err != nil {
+		return nil, err
+	}
+	if p.components[compPassword], err = compileComponent(processed["password"], canonicalizeUserinfo, patternOptions{}); err != nil {
+		return nil, err
+	}
+	hostEncode := canonicalizeHostname
+	if isIPv6HostnamePattern(processed["hostname"]) {
+		hostEncode = canonicalizeIPv6Hostname
+	}
+	if p.components[compHostname], err = compileComponent(processed["hostname"], hostEncode, patternOptions{delimiter: "."}); err != nil {
+		return nil, err
+	}
+	protocol := p.components[compProtocol]
+	if p.components[compPort], err = compileComponent(processed["port"], func(s string) (string, error) {
+		return canonicalizePort(s, "")
+	}, patternOptions{}); err != nil {
+		return nil, err
+	}
+	special := false
+	for scheme := range specialSchemes {
+		if protocol.match(scheme) != nil {
+			special = true
+			break
+		}
+	}
+	if special {
+		p.components[compPathname], err = compileComponent(processed["pathname"], canonicalizePathname, patternOptions{delimiter: "/", prefix: "/", ignoreCase: opts.IgnoreCase})
+	} else {
+		p.components[compPathname], err = compileComponent(processed["pathname"], canonicalizeOpaquePathname, patternOptions{ignoreCase: opts.IgnoreCase})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.components[compSearch], err = compileComponent(processed["search"], canonicalizeSearch, patternOptions{ignoreCase: opts.IgnoreCase}); err != nil {
+		return nil, err
+	}
+	if p.components[compHash], err = compileComponent(processed["hash"], canonicalizeHash, patternOptions{ignoreCase: opts.IgnoreCase}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Protocol returns the normalized pattern of the protocol component
+func (p *URLPattern) Protocol() string { return p.components[compProtocol].pattern }
+
+// Username returns the normalized pattern of the username component
+func (p *URLPattern) Username() string { return p.components[compUsername].pattern }
+
+// Password returns the normalized pattern of the password component
+func (p *URLPattern) Password() string { return p.components[compPassword].pattern }
+
+// Hostname returns the normalized pattern of the hostname component
+func (p *URLPattern) Hostname() string { return p.components[compHostname].pattern }
+
+// Port returns the normalized pattern of the port component
+func (p *URLPattern) Port() string { return p.components[compPort].pattern }
+
+// Pathname returns the normalized pattern of the pathname component
+func (p *URLPattern) Pathname() string { return p.components[compPathname].pattern }
+
+// Search returns the normalized pattern of the search component
+func (p *URLPattern) Search() string { return p.components[compSearch].pattern }
+
+// Hash returns the normalized pattern of the hash component
+func (p *URLPattern) Hash() string { return p.components[compHash].pattern }
+
+// HasRegExpGroups reports whether any component uses a custom regular expression group
+func (p *URLPattern) HasRegExpGroups() bool {
+	for _, c := range p.components {
+		if c.hasRegExpGroups {
+			return true
+		}
+	}
+	return false
+}
+
+// Test reports whether u matches the pattern
+func (p *URLPattern) Test(u *URL) bool {
+	return p.Exec(u) != nil
+}
+
+// Exec matches u against the pattern and returns the captured groups, or nil when it doesn't match
+func (p *URLPattern) Exec(u *URL) *URLPatternResult {
+	return p.exec(urlPatternInputs(u))
+}
+
+// ExecInit matches the components of init against the pattern, an error is returned when they are not valid URL components
+func (p *URLPattern) ExecInit(init URLPatternInit) (*URLPatternResult, error) {
+	processed, err := processPatternInit(init, false)
+	if err != nil {
+		return nil, err
+	}
+	var inputs [8]string
+	for i, name := range patternComponentNames {
+		inputs[i] = processed[name]
+	}
+	return p.exec(inputs), nil
+}
+
+func (p *URLPattern) exec(inputs [8]string) *URLPatternResult {
+	var results [8]URLPatternComponentResult
+	for i, c := range p.components {
+		groups := c.match(inputs[i])
+		if groups == nil {
+			return nil
+		}
+		results[i] = URLPatternComponentResult{Input: inputs[i], Groups: groups}
+	}
+	return &URLPatternResult{results[0], results[1], results[2], results[3], results[4], results[5], results[6], results[7]}
+}
+
+// urlPatternInputs returns the components of u the way the WHATWG URL standard exposes them
+func urlPatternInputs(u *URL) [8]string {
+	protocol := strings.ToLower(u.Protocol)
+	username, password := u.User, ""
+	if i := strings.IndexByte(username, ':'); i >= 0 {
+		username, password = username[:i], username[i+1:]
+	}
+
+	if u.opaque && !isSpecialScheme(protocol) {
+		opaque := URL{User: u.User, Host: u.Host, Port: u.Port, Path: u.Path, opaque: true}
+		path, _ := canonicalizeOpaquePathname(opaque.String())
+		search, _ := canonicalizeSearch(u.Query)
+		hash, _ := canonicalizeHash(u.Fragment)
+		return [8]string{protocol, "", "", "", "", path, search, hash}
+	}
+
+	port := u.Port
+	if port != "" {
+		if n, err := strconv.Atoi(port); err == nil {
+			port = strconv.Itoa(n)
+		}
+		if defaultPorts[protocol] == port && isSpecialScheme(protocol) {
+			port = ""
+		}
+	}
+
+	path := u.Path
+	if isSpecialScheme(protocol) {
+		path, _ = canonicalizePathname(strings.Replace(path, "\\", "/", -1))
+		if path == "" {
+			path = "/"
+		}
+	} else {
+		path, _ = canonicalizeOpaquePathname(path)
+	}
+
+	username, _ = canonicalizeUserinfo(username)
+	password, _ = canonicalizeUserinfo(password)
+	host, err := canonicalizeHostname(u.Host)
+	if err != nil {
+		host = strings.ToLower(u.Host)
+	}
+	search, _ := canonicalizeSearch(u.Query)
+	hash, _ := canonicalizeHash(u.Fragment)
+	return [8]string{protocol, username, password, host, port, path, search, hash}
+}
+
+// processPatternInit implements "process a URLPatternInit", pattern is false for inputs which are canonicalized instead of escaped
+func processPatternInit(init URLPatternInit, pattern bool) (URLPatternInit, error) {
+	result := URLPatternInit{}
+	has := func(names ...string) bool {
+		for _, n := range names {
+			if _, ok := init[n]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	processBase := func(s string) string {
+		if pattern {
+			return escapePatternString(s)
+		}
+		return s
+	}
+
+	var base *URL
+	if raw, ok := init["baseURL"]; ok {
+		var err error
+		if base, err = ParseURL(raw); err != nil || base.Protocol == "" {
+			return nil, patternError("invalid base URL %q", raw)
+		}
+		inputs := urlPatternInputs(base)
+		if !has("protocol") {
+			result["protocol"] = processBase(inputs[compProtocol])
+		}
+		if !pattern && !has("protocol", "hostname", "port", "username") {
+			result["username"] = processBase(inputs[compUsername])
+		}
+		if !pattern && !has("protocol", "hostname", "port", "username", "password") {
+			result["password"] = processBase(inputs[compPassword])
+		}
+		if !has("protocol", "hostname") {
+			result["hostname"] = processBase(inputs[compHostname])
+		}
+		if !has("protocol", "hostname", "port") {
+			result["port"] = inputs[compPort]
+		}
+		if !has("protocol", "hostname", "port", "pathname") {
+			result["pathname"] = processBase(inputs[compPathname])
+		}
+		if !has("protocol", "hostname", "port", "pathname", "search") {
+			result["search"] = processBase(inputs[compSearch])
+		}
+		if !has("protocol", "hostname", "port", "pathname", "search", "hash") {
+			result["hash"] = processBase(inputs[compHash])
+		}
+	}
+
+	process := func(name string, canonicalize func(string) (string, error)) error {
+		v, ok := init[name]
+		if !ok {
+			return nil
+		}
+		if !pattern {
+			var err error
+			if v, err = canonicalize(v); err != nil {
+				return patternError("invalid %s %q", name, init[name])
+			}
+		}
+		result[name] = v
+		return nil
+	}
+
+	if v, ok := init["protocol"]; ok {
+		result["protocol"] = strings.TrimSuffix(v, ":")
+		if !pattern {
+			p, err := canonicalizeProtocol(result["protocol"])
+			if err != nil {
+				return nil, patternError("invalid protocol %q", v)
+			}
+			result["protocol"] = p
+		}
+	}
+	if err := process("username", canonicalizeUserinfo); err != nil {
+		return nil, err
+	}
+	if err := process("password", canonicalizeUserinfo); err != nil {
+		return nil, err
+	}
+	if err := process("hostname", canonicalizeHostname); err != nil {
+		return nil, err
+	}
+	if err := process("port", func(s string) (string, error) { return canonicalizePort(s, result["protocol"]) }); err != nil {
+		return nil, err
+	}
+	if v, ok := init["pathname"]; ok {
+		if base != nil && !base.opaque && !isAbsolutePathname(v, pattern) {
+			basePath := processBase(urlPatternInputs(base)[compPathname])
+			if i := strings.LastIndexByte(basePath, '/'); i >= 0 {
+				v = basePath[:i+1] + v
+			}
+		}
+		if !pattern {
+			var err error
+			if isSpecialScheme(result["protocol"]) || result["protocol"] == "" {
+				v, err = canonicalizePathname(v)
+			} else {
+				v, err = canonicalizeOpaquePathname(v)
+			}
+			if err != nil {
+				return nil, patternError("invalid pathname %q", init["pathname"])
+			}
+		}
+		result["pathname"] = v
+	}
+	if v, ok := init["search"]; ok {
+		init = withValue(init, "search", strings.TrimPrefix(v, "?"))
+		if err := process("search", canonicalizeSearch); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := init["hash"]; ok {
+		init = withValue(init, "hash", strings.TrimPrefix(v, "#"))
+		if err := process("hash", canonicalizeHash); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func withValue(init URLPatternInit, key, value string) URLPatternInit {
+	c := make(URLPatternInit, len(init))
+	for k, v := range init {
+		c[k] = v
+	}
+	c[key] = value
+	return c
+}
+
+func isAbsolutePathname(s string, pattern bool) bool {
+	switch {
+	case s == "":
+		return false
+	case s[0] == '/':
+		return true
+	case !pattern || len(s) < 2:
+		return false
+	}
+	return (s[0] == '\\' || s[0] == '{') && s[1] == '/'
+}
+
+func canonicalizeProtocol(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.')) {
+			return "", errors.New("invalid scheme")
+		}
+	}
+	return strings.ToLower(s), nil
+}
+
+func canonicalizeUserinfo(s string) (string, error) {
+	return percentEncode(s, userinfoEncodeSet), nil
+}
+
+func isIPv6HostnamePattern(s string) bool {
+	return strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{[") || strings.HasPrefix(s, "\\[")
+}
+
+func canonicalizePort(s, protocol string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return "", errors.New("invalid port")
+		}
+		if n = n*10 + int(s[i]-'0'); n > 65535 {
+			return "", errors.New("port out of range")
+		}
+	}
+	port := strconv.Itoa(n)
+	if isSpecialScheme(protocol) && defaultPorts[protocol] == port {
+		return "", nil
+	}
+	return port, nil
+}
+
+func canonicalizePathname(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	leadingSlash := s[0] == '/'
+	if !leadingSlash {
+		s = "/-" + s
+	}
+	s = removeDotSegments(percentEncode(s, pathEncodeSet))
+	if !leadingSlash {
+		s = s[2:]
+	}
+	return s, nil
+}
+
+func canonicalizeOpaquePathname(s string) (string, error) {
+	return percentEncode(s, ""), nil
+}
+
+func canonicalizeSearch(s string) (string, error) {
+	return percentEncode(s, queryEncodeSet), nil
+}
+
+func canonicalizeHash(s string) (string, error) {
+	return percentEncode(s, fragmentEncodeSet), nil
+}
+
+// patternOptions are the options of the pattern string syntax for one component
+type patternOptions struct {
+	delimiter  string
+	prefix     string
+	ignoreCase bool
+}
+
+type patternComponent struct {
+	pattern         string
+	re              *regexp.Regexp
+	names           []string
+	hasRegExpGroups bool
+}
+
+// match returns the groups captured from input, or nil when it doesn't match
+func (c patternComponent) match(input string) map[string]string {
+	m := c.re.FindStringSubmatchIndex(input)
+	if m == nil {
+		return nil
+	}
+	groups := make(map[string]string, len(c.names))
+	for i, name := range c.names {
+		if start := m[2*i+2]; start >= 0 {
+			groups[name] = input[start:m[2*i+3]]
+		}
+	}
+	return groups
+}
+
+func compileComponent(input string, encode func(string) (string, error), opts patternOptions) (patternComponent, error) {
+	parts, err := parsePatternString(input, opts, encode)
+	if err != nil {
+		return patternComponent{}, err
+	}
+	expr, names := generatePatternRegexp(parts, opts)
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return patternComponent{}, patternError("%q: %v", input, err)
+	}
+	c := patternComponent{pattern: generatePatternString(parts, opts), re: re, names: names}
+	for _, p := range parts {
+		if p.kind == partRegexp {
+			c.hasRegExpGroups = true
+		}
+	}
+	return c, nil
+}
+
+type tokenType int
+
+const (
+	tokOpen tokenType = iota
+	tokClose
+	tokRegexp
+	tokName
+	tokChar
+	tokEscapedChar
+	tokOtherModifier
+	tokAsterisk
+	tokEnd
+	tokInvalidChar
+)
+
+type patternToken struct {
+	typ   tokenType
+	index int
+	value string
+}
+
+func isNameCodePoint(r rune, first bool) bool {
+	if r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r) || unicode.Is(unicode.Other_ID_Start, r) {
+		return true
+	}
+	if first {
+		return false
+	}
+	return r == '\u200c' || r == '\u200d' || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue)
+}
+
+// tokenizePattern implements the tokenizer of the URLPattern standard, lenient mode turns errors into invalid-char tokens
+func tokenizePattern(input string, lenient bool) ([]patternToken, error) {
+	var tokens []patternToken
+	fail := func(i, next int, msg string) error {
+		if !lenient {
+			return patternError("%s at %d in %q", msg, i, input)
+		}
+		tokens = append(tokens, patternToken{tokInvalidChar, i, input[i:next]})
+		return nil
+	}
+
+	i := 0
+	for i < len(input) {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch r {
+		case '*':
+			tokens = append(tokens, patternToken{tokAsterisk, i, "*"})
+			i++
+		case '+', '?':
+			tokens = append(tokens, patternToken{tokOtherModifier, i, input[i : i+1]})
+			i++
+		case '\\':
+			if i+1 == len(input) {
+				if err := fail(i, i+1, "trailing escape"); err != nil {
+					return nil, err
+				}
+				i++
+				continue
+			}
+			_, esize := utf8.DecodeRuneInString(input[i+1:])
+			tokens = append(tokens, patternToken{tokEscapedChar, i, input[i+1 : i+1+esize]})
+			i += 1 + esize
+		case '{':
+			tokens = append(tokens, patternToken{tokOpen, i, "{"})
+			i++
+		case '}':
+			tokens = append(tokens, patternToken{tokClose, i, "}"})
+			i++
+		case ':':
+			j := i + 1
+			for j < len(input) {
+				nr, nsize := utf8.DecodeRuneInString(input[j:])
+				if !isNameCodePoint(nr, j == i+1) {
+					break
+				}
+				j += nsize
+			}
+			if j == i+1 {
+				if err := fail(i, i+1, "missing parameter name"); err != nil {
+					return nil, err
+				}
+				i++
+				continue
+			}
+			tokens = append(tokens, patternToken{tokName, i, input[i+1 : j]})
+			i = j
+		case '(':
+			end, msg := scanPatternRegexp(input, i)
+			if msg != "" {
+				if err := fail(i, i+1, msg); err != nil {
+					return nil, err
+				}
+				i++
+				continue
+			}
+			tokens = append(tokens, patternToken{tokRegexp, i, input[i+1 : end-1]})
+			i = end
+		default:
+			tokens = append(tokens, patternToken{tokChar, i, input[i : i+size]})
+			i += size
+		}
+	}
+	return append(tokens, patternToken{tokEnd, len(input), ""}), nil
+}
+
+// scanPatternRegexp returns the index past the regexp group starting at input[start] or why it is invalid
+func scanPatternRegexp(input string, start int) (int, string) {
+	depth, i := 1, start+1
+	for i < len(input) {
+		c := input[i]
+		if c >= utf8.RuneSelf {
+			return 0, "non-ASCII regexp"
+		}
+		if i == start+1 && c == '?' {
+			return 0, "regexp group starting with ?"
+		}
+		if c == '\\' {
+			if i == len(input)-1 || input[i+1] >= utf8.RuneSelf {
+				return 0, "invalid regexp escape"
+			}
+			i += 2
+			continue
+		}
+		if c == ')' {
+			depth--
+			if depth == 0 {
+				i++
+				break
+			}
+		} else if c == '(' {
+			depth++
+			if i == len(input)-1 || input[i+1] != '?' {
+				return 0, "capturing group inside regexp"
+			}
+		}
+		i++
+	}
+	if depth != 0 {
+		return 0, "unbalanced regexp"
+	}
+	if i-start-2 == 0 {
+		return 0, "empty regexp"
+	}
+	return i, ""
+}
+
+type partType int
+
+const (
+	partFixedText partType = iota
+	partRegexp
+	partSegmentWildcard
+	partFullWildcard
+)
+
+type partModifier string
+
+const (
+	modNone       partModifier = ""
+	modOptional   partModifier = "?"
+	modZeroOrMore partModifier = "*"
+	modOneOrMore  partModifier = "+"
+)
+
+type patternPart struct {
+	kind     partType
+	value    string
+	modifier partModifier
+	name     string
+	prefix   string
+	suffix   string
+}
+
+const fullWildcardRegexp = ".*"
+
+func segmentWildcardRegexp(opts patternOptions) string {
+	return "[^" + escapeRegexpString(opts.delimiter) + "]+?"
+}
+
+func escapePatternString(s string) string {
+	return escapeWith(s, "+*?:{}()\\")
+}
+
+func escapeRegexpString(s string) string {
+	return escapeWith(s, ".+*?^${}()[]|/\\")
+}
+
+func escapeWith(s, special string) string {
+	if !strings.ContainsAny(s, special) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(special, s[i]) >= 0 {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+type patternParser struct {
+	tokens      []patternToken
+	index       int
+	opts        patternOptions
+	encode      func(string) (string, error)
+	parts       []patternPart
+	pending     string
+	nextNumName int
+}
+
+// parsePatternString implements "parse a pattern string"
+func parsePatternString(input string, opts patternOptions, encode func(string) (string, error)) ([]patternPart, error) {
+	tokens, err := tokenizePattern(input, false)
+	if err != nil {
+		return nil, err
+	}
+	p := &patternParser{tokens: tokens, opts: opts, encode: encode}
+
+	for p.index < len(p.tokens) {
+		charTok := p.tryConsume(tokChar)
+		nameTok := p.tryConsume(tokName)
+		regexpTok := p.tryConsumeRegexpOrWildcard(nameTok)
+		if nameTok != nil || regexpTok != nil {
+			prefix := ""
+			if charTok != nil {
+				prefix = charTok.value
+			}
+			if prefix != "" && prefix != opts.prefix {
+				p.pending += prefix
+				prefix = ""
+			}
+			if err := p.flushPending(); err != nil {
+				return nil, err
+			}
+			modTok := p.tryConsumeModifier()
+			if err := p.addPart(prefix, nameTok, regexpTok, "", modTok); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		fixed := charTok
+		if fixed == nil {
+			fixed = p.tryConsume(tokEscapedChar)
+		}
+		if fixed != nil {
+			p.pending += fixed.value
+			continue
+		}
+
+		if p.tryConsume(tokOpen) != nil {
+			prefix := p.consumeText()
+			nameTok := p.tryConsume(tokName)
+			regexpTok := p.tryConsumeRegexpOrWildcard(nameTok)
+			suffix := p.consumeText()
+			if p.tryConsume(tokClose) == nil {
+				return nil, patternError("missing } in %q", input)
+			}
+			modTok := p.tryConsumeModifier()
+			if err := p.addPart(prefix, nameTok, regexpTok, suffix, modTok); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := p.flushPending(); err != nil {
+			return nil, err
+		}
+		if p.tryConsume(tokEnd) == nil {
+			return nil, patternError("unexpected %q in %q", p.tokens[p.index].value, input)
+		}
+	}
+	return p.parts, nil
+}
+
+func (p *patternParser) tryConsume(typ tokenType) *patternToken {
+	if p.index >= len(p.tokens) || p.tokens[p.index].typ != typ {
+		return nil
+	}
+	t := &p.tokens[p.index]
+	p.index++
+	return t
+}
+
+func (p *patternParser) tryConsumeModifier() *patternToken {
+	if t := p.tryConsume(tokOtherModifier); t != nil {
+		return t
+	}
+	return p.tryConsume(tokAsterisk)
+}
+
+func (p *patternParser) tryConsumeRegexpOrWildcard(nameTok *patternToken) *patternToken {
+	if t := p.tryConsume(tokRegexp); t != nil || nameTok != nil {
+		return t
+	}
+	return p.tryConsume(tokAsterisk)
+}
+
+func (p *patternParser) consumeText() string {
+	var sb strings.Builder
+	for {
+		t := p.tryConsume(tokChar)
+		if t == nil {
+			t = p.tryConsume(tokEscapedChar)
+		}
+		if t == nil {
+			return sb.String()
+		}
+		sb.WriteString(t.value)
+	}
+}
+
+func (p *patternParser) flushPending() error {
+	if p.pending == "" {
+		return nil
+	}
+	encoded, err := p.encode(p.pending)
+	if err != nil {
+		return patternError("%q: %v", p.pending, err)
+	}
+	p.pending = ""
+	p.parts = append(p.parts, patternPart{kind: partFixedText, value: encoded})
+	return nil
+}
+
+func (p *patternParser) addPart(prefix string, nameTok, regexpTok *patternToken, suffix string, modTok *patternToken) error {
+	modifier := modNone
+	if modTok != nil {
+		modifier = partModifier(modTok.value)
+	}
+	if nameTok == nil && regexpTok == nil && modifier == modNone {
+		p.pending += prefix
+		return nil
+	}
+	if err := p.flushPending(); err != nil {
+		return err
+	}
+	if nameTok == nil && regexpTok == nil {
+		if prefix == "" {
+			return nil
+		}
+		encoded, err := p.encode(prefix)
+		if err != nil {
+			return patternError("%q: %v", prefix, err)
+		}
+		p.parts = append(p.parts, patternPart{kind: partFixedText, value: encoded, modifier: modifier})
+		return nil
+	}
+
+	value := ""
+	switch {
+	case regexpTok == nil:
+		value = segmentWildcardRegexp(p.opts)
+	case regexpTok.typ == tokAsterisk:
+		value = fullWildcardRegexp
+	default:
+		value = regexpTok.value
+	}
+	kind := partRegexp
+	if value == segmentWildcardRegexp(p.opts) {
+		kind, value = partSegmentWildcard, ""
+	} else if value == fullWildcardRegexp {
+		kind, value = partFullWildcard, ""
+	}
+
+	name := ""
+	if nameTok != nil {
+		name = nameTok.value
+	} else {
+		name = strconv.Itoa(p.nextNumName)
+		p.nextNumName++
+	}
+	for _, part := range p.parts {
+		if part.name == name {
+			return patternError("duplicate group name %q", name)
+		}
+	}
+
+	encodedPrefix, err := p.encode(prefix)
+	if err != nil {
+		return patternError("%q: %v", prefix, err)
+	}
+	encodedSuffix, err := p.encode(suffix)
+	if err != nil {
+		return patternError("%q: %v", suffix, err)
+	}
+	p.parts = append(p.parts, patternPart{kind: kind, value: value, modifier: modifier, name: name, prefix: encodedPrefix, suffix: encodedSuffix})
+	return nil
+}
+
+// generatePatternRegexp returns the RE2 expression matching parts and the names of its capture groups
+func generatePatternRegexp(parts []patternPart, opts patternOptions) (string, []string) {
+	var sb strings.Builder
+	if opts.ignoreCase {
+		sb.WriteString("(?i)")
+	}
+	sb.WriteString("^")
+	var names []string
+	for _, part := range parts {
+		mod := string(part.modifier)
+		if part.kind == partFixedText {
+			if part.modifier == modNone {
+				sb.WriteString(regexp.QuoteMeta(part.value))
+			} else {
+				sb.WriteString("(?:" + regexp.QuoteMeta(part.value) + ")" + mod)
+			}
+			continue
+		}
+
+		names = append(names, part.name)
+		value := part.value
+		switch part.kind {
+		case partSegmentWildcard:
+			value = "[^" + regexp.QuoteMeta(opts.delimiter) + "]+?"
+			if opts.delimiter == "" {
+				value = "(?s:.)+?"
+			}
+		case partFullWildcard:
+			value = fullWildcardRegexp
+		}
+
+		prefix, suffix := regexp.QuoteMeta(part.prefix), regexp.QuoteMeta(part.suffix)
+		if prefix == "" && suffix == "" {
+			if part.modifier == modNone || part.modifier == modOptional {
+				sb.WriteString("(" + value + ")" + mod)
+			} else {
+				sb.WriteString("((?:" + value + ")" + mod + ")")
+			}
+			continue
+		}
+		if part.modifier == modNone || part.modifier == modOptional {
+			sb.WriteString("(?:" + prefix + "(" + value + ")" + suffix + ")" + mod)
+			continue
+		}
+		sb.WriteString("(?:" + prefix + "((?:" + value + ")(?:" + suffix + prefix + "(?:" + value + "))*)" + suffix + ")")
+		if part.modifier == modZeroOrMore {
+			sb.WriteString("?")
+		}
+	}
+	sb.WriteString("$")
+	return sb.String(), names
+}
+
+// generatePatternString implements "generate a pattern string", the normalized form returned by the component getters
+func generatePatternString(parts []patternPart, opts patternOptions) string {
+	var sb strings.Builder
+	for i, part := range parts {
+		var prev, next *patternPart
+		if i > 0 {
+			prev = &parts[i-1]
+		}
+		if i < len(parts)-1 {
+			next = &parts[i+1]
+		}
+
+		if part.kind == partFixedText {
+			if part.modifier == modNone {
+				sb.WriteString(escapePatternString(part.value))
+			} else {
+				sb.WriteString("{" + escapePatternString(part.value) + "}" + string(part.modifier))
+			}
+			continue
+		}
+
+		customName := !isASCIIDigit(part.name[0])
+		needsGrouping := part.suffix != "" || part.prefix != "" && part.prefix != opts.prefix
+		if !needsGrouping && customName && part.kind == partSegmentWildcard && part.modifier == modNone &&
+			next != nil && next.prefix == "" && next.suffix == "" {
+			if next.kind == partFixedText {
+				r, _ := utf8.DecodeRuneInString(next.value)
+				needsGrouping = isNameCodePoint(r, false)
+			} else {
+				needsGrouping = isASCIIDigit(next.name[0])
+			}
+		}
+		if !needsGrouping && part.prefix == "" && prev != nil && prev.kind == partFixedText &&
+			opts.prefix != "" && strings.HasSuffix(prev.value, opts.prefix) {
+			needsGrouping = true
+		}
+
+		if needsGrouping {
+			sb.WriteString("{")
+		}
+		sb.WriteString(escapePatternString(part.prefix))
+		if customName {
+			sb.WriteString(":" + part.name)
+		}
+		switch part.kind {
+		case partRegexp:
+			sb.WriteString("(" + part.value + ")")
+		case partSegmentWildcard:
+			if !customName {
+				sb.WriteString("(" + segmentWildcardRegexp(opts) + ")")
+			}
+		case partFullWildcard:
+			if !customName && (prev == nil || prev.kind == partFixedText || prev.modifier != modNone || needsGrouping || part.prefix != "") {
+				sb.WriteString("*")
+			} else {
+				sb.WriteString("(" + fullWildcardRegexp + ")")
+			}
+		}
+		if part.kind == partSegmentWildcard && customName && part.suffix != "" {
+			if r, _ := utf8.DecodeRuneInString(part.suffix); isNameCodePoint(r, false) {
+				sb.WriteString("\\")
+			}
+		}
+		sb.WriteString(escapePatternString(part.suffix))
+		if needsGrouping {
+			sb.WriteString("}")
+		}
+		sb.WriteString(string(part.modifier))
+	}
+	return sb.String()
+}
+
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+type constructorState int
+
+const (
+	csInit constructorState = iota
+	csProtocol
+	csAuthority
+	csUsername
+	csPassword
+	csHostname
+	csPort
+	csPathname
+	csSearch
+	csHash
+	csDone
+)
+
+// constructorStateComponents maps the states that produce a component to its name
+var constructorStateComponents = map[constructorState]string{
+	csProtocol: "protocol",
+	csUsername: "username",
+	csPassword: "password",
+	csHostname: "hostname",
+	csPort:     "port",
+	csPathname: "pathname",
+	csSearch:   "search",
+	csHash:     "hash",
+}
+
+type constructorParser struct {
+	input             string
+	tokens            []patternToken
+	result            URLPatternInit
+	componentStart    int
+	tokenIndex        int
+	tokenIncrement    int
+	groupDepth        int
+	ipv6BracketDepth  int
+	protocolIsSpecial bool
+	state             constructorState
+}
+
+// parseConstructorString implements "parse a constructor string", splitting a pattern string into components
+func parseConstructorString(input string) (URLPatternInit, error) {
+	tokens, _ := tokenizePattern(input, true)
+	p := &constructorParser{input: input, tokens: tokens, result: URLPatternInit{}, tokenIncrement: 1}
+
+	for p.tokenIndex < len(p.tokens) {
+		p.tokenIncrement = 1
+		if p.tokens[p.tokenIndex].typ == tokEnd {
+			if p.state == csInit {
+				p.rewind()
+				switch {
+				case p.isHashPrefix():
+					p.changeState(csHash, 1)
+				case p.isSearchPrefix():
+					p.changeState(csSearch, 1)
+				default:
+					p.changeState(csPathname, 0)
+				}
+				p.tokenIndex += p.tokenIncrement
+				continue
+			}
+			if p.state == csAuthority {
+				p.rewind()
+				p.state = csHostname
+				p.tokenIndex += p.tokenIncrement
+				continue
+			}
+			p.changeState(csDone, 0)
+			break
+		}
+
+		if p.tokens[p.tokenIndex].typ == tokOpen {
+			p.groupDepth++
+			p.tokenIndex += p.tokenIncrement
+			continue
+		}
+		if p.groupDepth > 0 {
+			if p.tokens[p.tokenIndex].typ != tokClose {
+				p.tokenIndex += p.tokenIncrement
+				continue
+			}
+			p.groupDepth--
+		}
+
+		switch p.state {
+		case csInit:
+			if p.isNonSpecial(p.tokenIndex, ":") {
+				p.rewind()
+				p.state = csProtocol
+			}
+		case csProtocol:
+			if p.isNonSpecial(p.tokenIndex, ":") {
+				if err := p.computeProtocolIsSpecial(); err != nil {
+					return nil, err
+				}
+				next, skip := csPathname, 1
+				if p.isNonSpecial(p.tokenIndex+1, "/") && p.isNonSpecial(p.tokenIndex+2, "/") {
+					next, skip = csAuthority, 3
+				} else if p.protocolIsSpecial {
+					next = csAuthority
+				}
+				p.changeState(next, skip)
+			}
+		case csAuthority:
+			if p.isNonSpecial(p.tokenIndex, "@") {
+				p.rewind()
+				p.state = csUsername
+			} else if p.isNonSpecial(p.tokenIndex, "/") || p.isSearchPrefix() || p.isHashPrefix() {
+				p.rewind()
+				p.state = csHostname
+			}
+		case csUsername:
+			if p.isNonSpecial(p.tokenIndex, ":") {
+				p.changeState(csPassword, 1)
+			} else if p.isNonSpecial(p.tokenIndex, "@") {
+				p.changeState(csHostname, 1)
+			}
+		case csPassword:
+			if p.isNonSpecial(p.tokenIndex, "@") {
+				p.changeState(csHostname, 1)
+			}
+		case csHostname:
+			switch {
+			case p.isNonSpecial(p.tokenIndex, "["):
+				p.ipv6BracketDepth++
+			case p.isNonSpecial(p.tokenIndex, "]"):
+				p.ipv6BracketDepth--
+			case p.isNonSpecial(p.tokenIndex, ":") && p.ipv6BracketDepth == 0:
+				p.changeState(csPort, 1)
+			case p.isNonSpecial(p.tokenIndex, "/"):
+				p.changeState(csPathname, 0)
+			case p.isSearchPrefix():
+				p.changeState(csSearch, 1)
+			case p.isHashPrefix():
+				p.changeState(csHash, 1)
+			}
+		case csPort:
+			switch {
+			case p.isNonSpecial(p.tokenIndex, "/"):
+				p.changeState(csPathname, 0)
+			case p.isSearchPrefix():
+				p.changeState(csSearch, 1)
+			case p.isHashPrefix():
+				p.changeState(csHash, 1)
+			}
+		case csPathname:
+			if p.isSearchPrefix() {
+				p.changeState(csSearch, 1)
+			} else if p.isHashPrefix() {
+				p.changeState(csHash, 1)
+			}
+		case csSearch:
+			if p.isHashPrefix() {
+				p.changeState(csHash, 1)
+			}
+		}
+		p.tokenIndex += p.tokenIncrement
+	}
+
+	if _, ok := p.result["hostname"]; ok {
+		if _, ok := p.result["port"]; !ok {
+			p.result["port"] = ""
+		}
+	}
+	return p.result, nil
+}
+
+func (p *constructorParser) rewind() {
+	p.tokenIndex = p.componentStart
+	p.tokenIncrement = 0
+}
+
+func (p *constructorParser) changeState(next constructorState, skip int) {
+	if name, ok := constructorStateComponents[p.state]; ok {
+		p.result[name] = p.componentString()
+	}
+	if p.state != csInit && next != csDone {
+		_, hasHostname := p.result["hostname"]
+		_, hasPathname := p.result["pathname"]
+		_, hasSearch := p.result["search"]
+		if p.state <= csPassword && next >= csPort && !hasHostname {
+			p.result["hostname"] = ""
+		}
+		if p.state <= csPort && next >= csSearch && !hasPathname {
+			if p.protocolIsSpecial {
+				p.result["pathname"] = "/"
+			} else {
+				p.result["pathname"] = ""
+			}
+		}
+		if p.state <= csPathname && next == csHash && !hasSearch {
+			p.result["search"] = ""
+		}
+	}
+	p.state = next
+	p.tokenIndex += skip
+	p.componentStart = p.tokenIndex
+	p.tokenIncrement = 0
+}
+
+func (p *constructorParser) safeToken(i int) patternToken {
+	if i < len(p.tokens) {
+		return p.tokens[i]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *constructorParser) componentString() string {
+	start := p.safeToken(p.componentStart).index
+	return p.input[start:p.tokens[p.tokenIndex].index]
+}
+
+func (p *constructorParser) isNonSpecial(i int, value string) bool {
+	t := p.safeToken(i)
+	return t.value == value && (t.typ == tokChar || t.typ == tokEscapedChar || t.typ == tokInvalidChar)
+}
+
+func (p *constructorParser) isSearchPrefix() bool {
+	if p.isNonSpecial(p.tokenIndex, "?") {
+		return true
+	}
+	if p.tokens[p.tokenIndex].value != "?" {
+		return false
+	}
+	if p.tokenIndex == 0 {
+		return true
+	}
+	switch p.safeToken(p.tokenIndex - 1).typ {
+	case tokName, tokRegexp, tokClose, tokAsterisk:
+		return false
+	}
+	return true
+}
+
+func (p *constructorParser) isHashPrefix() bool {
+	return p.isNonSpecial(p.tokenIndex, "#")
+}
+
+func (p *constructorParser) computeProtocolIsSpecial() error {
+	c, err := compileComponent(p.componentString(), canonicalizeProtocol, patternOptions{})
+	if err != nil {
+		return err
+	}
+	for scheme := range specialSchemes {
+		if c.match(scheme) != nil {
+			p.protocolIsSpecial = true
+			return nil
+		}
+	}
+	return nil
+}
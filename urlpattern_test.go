@@ -0,0 +1,203 @@
+package fasturl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// urlPatternTestCase is an entry of the web-platform-tests urlpatterntestdata.json, the format of
+// testdata/urlpattern_cases.json too
+type urlPatternTestCase struct {
+	Pattern                []json.RawMessage `json:"pattern"`
+	Inputs                 []json.RawMessage `json:"inputs"`
+	ExpectedObj            json.RawMessage   `json:"expected_obj"`
+	ExpectedMatch          json.RawMessage   `json:"expected_match"`
+	ExactlyEmptyComponents []string          `json:"exactly_empty_components"`
+}
+
+type urlPatternExpectedComponent struct {
+	Input  string             `json:"input"`
+	Groups map[string]*string `json:"groups"`
+}
+
+func newTestURLPattern(t *testing.T, args []json.RawMessage) (*URLPattern, error) {
+	var opts URLPatternOptions
+	if last := args[len(args)-1]; len(args) > 1 && last[0] == '{' {
+		require.NoError(t, json.Unmarshal(last, &opts))
+	}
+
+	var pattern string
+	if json.Unmarshal(args[0], &pattern) == nil {
+		var base string
+		if len(args) > 1 {
+			json.Unmarshal(args[1], &base)
+		}
+		return NewURLPattern(pattern, base, opts)
+	}
+	var init URLPatternInit
+	require.NoError(t, json.Unmarshal(args[0], &init))
+	return NewURLPatternInit(init, opts)
+}
+
+func execTestURLPattern(t *testing.T, p *URLPattern, args []json.RawMessage) (*URLPatternResult, error) {
+	var input string
+	if json.Unmarshal(args[0], &input) != nil {
+		var init URLPatternInit
+		require.NoError(t, json.Unmarshal(args[0], &init))
+		return p.ExecInit(init)
+	}
+
+	var u *URL
+	var err error
+	if len(args) > 1 {
+		var base string
+		require.NoError(t, json.Unmarshal(args[1], &base))
+		b, err := ParseURLSplit(base)
+		require.NoError(t, err)
+		u, err = b.Resolve(input)
+	} else {
+		u, err = ParseURLSplit(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if u.Protocol == "" {
+		return nil, fmt.Errorf("%q isn't absolute", input)
+	}
+	return p.Exec(u), nil
+}
+
+// urlPatternWPTSkips are the cases of the upstream data not supported yet, keyed by their JSON pattern followed by a space
+// and their first JSON input, with the reason
+var urlPatternWPTSkips = map[string]string{}
+
+// TestURLPatternWPT runs the unmodified web-platform-tests data, fetched into testdata with `make wpt`
+func TestURLPatternWPT(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/urlpatterntestdata.json")
+	if os.IsNotExist(err) {
+		t.Skip("testdata/urlpatterntestdata.json is missing, fetch it with `make wpt`")
+	}
+	require.NoError(t, err)
+	runURLPatternData(t, data, urlPatternWPTSkips)
+}
+
+// TestURLPatternData runs regression cases written in the format of the upstream data
+func TestURLPatternData(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/urlpattern_cases.json")
+	require.NoError(t, err)
+	runURLPatternData(t, data, nil)
+}
+
+func runURLPatternData(t *testing.T, data []byte, skips map[string]string) {
+	var cases []urlPatternTestCase
+	require.NoError(t, json.Unmarshal(data, &cases))
+
+	for _, tc := range cases {
+		tc := tc
+		name := string(tc.Pattern[0])
+		if len(tc.Inputs) > 0 {
+			name += " " + string(tc.Inputs[0])
+		}
+		t.Run(name, func(t *testing.T) {
+			if reason, ok := skips[name]; ok {
+				t.Skip(reason)
+			}
+			p, err := newTestURLPattern(t, tc.Pattern)
+			if string(tc.ExpectedObj) == `"error"` {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var obj map[string]string
+			if len(tc.ExpectedObj) > 0 {
+				require.NoError(t, json.Unmarshal(tc.ExpectedObj, &obj))
+			}
+			getters := map[string]func() string{
+				"protocol": p.Protocol, "username": p.Username, "password": p.Password, "hostname": p.Hostname,
+				"port": p.Port, "pathname": p.Pathname, "search": p.Search, "hash": p.Hash,
+			}
+			for name, want := range obj {
+				assert.Equal(t, want, getters[name](), name)
+			}
+
+			if tc.Inputs == nil {
+				return
+			}
+			res, err := execTestURLPattern(t, p, tc.Inputs)
+			if string(tc.ExpectedMatch) == `"error"` {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if string(tc.ExpectedMatch) == "null" {
+				assert.Nil(t, res)
+				return
+			}
+			require.NotNil(t, res)
+
+			var expected map[string]urlPatternExpectedComponent
+			require.NoError(t, json.Unmarshal(tc.ExpectedMatch, &expected))
+			got := map[string]URLPatternComponentResult{
+				"protocol": res.Protocol, "username": res.Username, "password": res.Password, "hostname": res.Hostname,
+				"port": res.Port, "pathname": res.Pathname, "search": res.Search, "hash": res.Hash,
+			}
+			for _, name := range patternComponentNames {
+				want, ok := expected[name]
+				if !ok {
+					want.Groups = map[string]*string{"0": new(string)}
+					for _, empty := range tc.ExactlyEmptyComponents {
+						if empty == name {
+							want.Groups = map[string]*string{}
+						}
+					}
+				}
+				groups := map[string]string{}
+				for k, v := range want.Groups {
+					if v != nil {
+						groups[k] = *v
+					}
+				}
+				assert.Equal(t, want.Input, got[name].Input, name)
+				assert.Equal(t, groups, got[name].Groups, name)
+			}
+		})
+	}
+}
+
+func TestURLPattern(t *testing.T) {
+	p, err := NewURLPattern(`https://*.example.com/books/:id(\d+)?*`, "", URLPatternOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `/books/:id(\d+)?*`, p.Pathname())
+	assert.True(t, p.HasRegExpGroups())
+
+	t.Run("Captures", func(t *testing.T) {
+		res := p.Exec(mustParse(t, "https://shop.example.com/books/42/reviews"))
+		require.NotNil(t, res)
+		assert.Equal(t, map[string]string{"0": "shop"}, res.Hostname.Groups)
+		assert.Equal(t, map[string]string{"id": "42", "0": "/reviews"}, res.Pathname.Groups)
+	})
+
+	t.Run("Optional group", func(t *testing.T) {
+		res := p.Exec(mustParse(t, "https://shop.example.com/books"))
+		require.NotNil(t, res)
+		assert.Equal(t, map[string]string{"0": ""}, res.Pathname.Groups)
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		assert.False(t, p.Test(mustParse(t, "https://example.com/books/42")))
+		assert.False(t, p.Test(mustParse(t, "http://shop.example.com/books/42")))
+		assert.False(t, p.Test(mustParse(t, "https://shop.example.com/music/42")))
+	})
+
+	t.Run("Unsupported regexp", func(t *testing.T) {
+		_, err := NewURLPattern(`https://example.com/((?<=a)b)`, "", URLPatternOptions{})
+		assert.Error(t, err)
+	})
+}
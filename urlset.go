@@ -0,0 +1,214 @@
+package fasturl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"unsafe"
+)
+
+const urlSetShards = 64
+
+// urlSetEntryBytes and urlSetMapBytes roughly estimate what an entry costs in a map keyed by a 128-bit fingerprint
+// and what an empty map costs. Maps grow by doubling, so depending on how full its table is an entry really costs
+// between about 20 and 50 bytes, slack and control bytes included.
+const (
+	urlSetEntryBytes = 40
+	urlSetMapBytes   = 48
+)
+
+const urlSetMagic = "FURLSET\x01"
+
+var errURLSetFormat = errors.New("fasturl: not a URL set file")
+
+// URLSet is a set of URLs deduplicated by their `Fingerprint128`, so URLs that normalize alike are stored once.
+// Only fingerprints are kept, which makes the set small but unable to list its URLs.
+// It is safe for concurrent use, the fingerprints are spread over independently locked shards.
+type URLSet struct {
+	opts   FingerprintOptions
+	shards [urlSetShards]urlSetShard
+}
+
+type urlSetShard struct {
+	mu sync.RWMutex
+	m  map[[2]uint64]struct{}
+}
+
+// NewURLSet returns an empty set deduplicating with opts
+func NewURLSet(opts FingerprintOptions) *URLSet {
+	s := &URLSet{opts: opts}
+	for i := range s.shards {
+		s.shards[i].m = map[[2]uint64]struct{}{}
+	}
+	return s
+}
+
+// Options returns the fingerprint options of the set
+func (s *URLSet) Options() FingerprintOptions {
+	return s.opts
+}
+
+func (s *URLSet) key(u *URL) ([2]uint64, *urlSetShard) {
+	hi, lo := Fingerprint128(u, s.opts)
+	return [2]uint64{hi, lo}, &s.shards[hi%urlSetShards]
+}
+
+// Add adds u and reports whether it wasn't in the set yet
+func (s *URLSet) Add(u *URL) bool {
+	k, shard := s.key(u)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if _, ok := shard.m[k]; ok {
+		return false
+	}
+	shard.m[k] = struct{}{}
+	return true
+}
+
+// AddString parses raw with `ParseURL` and adds it
+func (s *URLSet) AddString(raw string) (bool, error) {
+	u, err := ParseURL(raw)
+	if err != nil {
+		return false, err
+	}
+	return s.Add(u), nil
+}
+
+// Contains reports whether u, or a URL that normalizes like it, is in the set
+func (s *URLSet) Contains(u *URL) bool {
+	k, shard := s.key(u)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	_, ok := shard.m[k]
+	return ok
+}
+
+// ContainsString parses raw with `ParseURL` and checks whether it is in the set
+func (s *URLSet) ContainsString(raw string) (bool, error) {
+	u, err := ParseURL(raw)
+	if err != nil {
+		return false, err
+	}
+	return s.Contains(u), nil
+}
+
+// Len returns the number of distinct URLs in the set
+func (s *URLSet) Len() int {
+	n := 0
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.RLock()
+		n += len(shard.m)
+		shard.mu.RUnlock()
+	}
+	return n
+}
+
+// MemoryUsage roughly estimates the bytes held by the set, it is meant for capacity planning and may be off by a few
+// tens of percent either way depending on how full the maps are
+func (s *URLSet) MemoryUsage() int64 {
+	return int64(unsafe.Sizeof(*s)) + urlSetShards*urlSetMapBytes + int64(s.Len())*urlSetEntryBytes
+}
+
+// WriteTo serializes the set: a magic number, a flags byte for the options, the little-endian count and the fingerprints
+func (s *URLSet) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var header [17]byte
+	copy(header[:], urlSetMagic)
+	header[8] = s.flags()
+
+	// hold every shard so the count matches the fingerprints that follow
+	for i := range s.shards {
+		s.shards[i].mu.RLock()
+		defer s.shards[i].mu.RUnlock()
+	}
+	n := 0
+	for i := range s.shards {
+		n += len(s.shards[i].m)
+	}
+	binary.LittleEndian.PutUint64(header[9:], uint64(n))
+	written, err := bw.Write(header[:])
+	total := int64(written)
+	if err != nil {
+		return total, err
+	}
+
+	var buf [16]byte
+	for i := range s.shards {
+		for k := range s.shards[i].m {
+			binary.LittleEndian.PutUint64(buf[:8], k[0])
+			binary.LittleEndian.PutUint64(buf[8:], k[1])
+			written, err := bw.Write(buf[:])
+			total += int64(written)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, bw.Flush()
+}
+
+// ReadFrom adds the fingerprints of a set serialized by `URLSet.WriteTo`, which must use the same options
+func (s *URLSet) ReadFrom(r io.Reader) (int64, error) {
+	br := bufio.NewReader(r)
+	var header [17]byte
+	read, err := io.ReadFull(br, header[:])
+	total := int64(read)
+	if err != nil {
+		return total, err
+	}
+	if string(header[:8]) != urlSetMagic {
+		return total, errURLSetFormat
+	}
+	if header[8] != s.flags() {
+		return total, errors.New("fasturl: URL set file was written with different fingerprint options")
+	}
+
+	var buf [16]byte
+	for n := binary.LittleEndian.Uint64(header[9:]); n > 0; n-- {
+		read, err := io.ReadFull(br, buf[:])
+		total += int64(read)
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return total, err
+		}
+		k := [2]uint64{binary.LittleEndian.Uint64(buf[:8]), binary.LittleEndian.Uint64(buf[8:])}
+		shard := &s.shards[k[0]%urlSetShards]
+		shard.mu.Lock()
+		shard.m[k] = struct{}{}
+		shard.mu.Unlock()
+	}
+	return total, nil
+}
+
+// LoadURLSet reads a set serialized by `URLSet.WriteTo`, taking its options from the file
+func LoadURLSet(r io.Reader) (*URLSet, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(9)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:8]) != urlSetMagic || header[8]&^3 != 0 {
+		return nil, errURLSetFormat
+	}
+	s := NewURLSet(FingerprintOptions{IgnoreFragment: header[8]&1 != 0, IgnoreQueryOrder: header[8]&2 != 0})
+	if _, err := s.ReadFrom(br); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *URLSet) flags() byte {
+	var f byte
+	if s.opts.IgnoreFragment {
+		f |= 1
+	}
+	if s.opts.IgnoreQueryOrder {
+		f |= 2
+	}
+	return f
+}
@@ -0,0 +1,125 @@
+package fasturl
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLSet(t *testing.T) {
+	s := NewURLSet(FingerprintOptions{IgnoreFragment: true})
+	for _, tc := range []struct {
+		url   string
+		added bool
+	}{
+		{"http://example.com/a/b", true},
+		{"HTTP://Example.COM:80/a/./b", false},
+		{"http://example.com/a/b#section", false},
+		{"http://example.com/a/%62", false},
+		{"https://example.com/a/b", true},
+		{"http://example.com/a/b?x=1", true},
+	} {
+		added, err := s.AddString(tc.url)
+		require.NoError(t, err)
+		assert.Equal(t, tc.added, added, tc.url)
+	}
+	assert.Equal(t, 3, s.Len())
+
+	ok, err := s.ContainsString("http://EXAMPLE.com/a/b")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = s.ContainsString("http://example.com/a/c")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = s.AddString("http://example.com/a?b?c")
+	assert.Error(t, err)
+}
+
+func TestURLSetMemoryUsage(t *testing.T) {
+	heapAlloc := func() uint64 {
+		runtime.GC()
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return m.HeapAlloc
+	}
+
+	const n = 100000
+	before := heapAlloc()
+	s := NewURLSet(FingerprintOptions{})
+	for i := 0; i < n; i++ {
+		s.Add(&URL{Protocol: "https", Host: "example.com", Path: "/item/" + strconv.Itoa(i)})
+	}
+	held := float64(heapAlloc() - before)
+	runtime.KeepAlive(s)
+
+	estimate := float64(s.MemoryUsage())
+	assert.InDelta(t, 1, estimate/held, 0.5, "estimated %.0f bytes, the heap grew by %.0f", estimate, held)
+}
+
+func TestURLSetConcurrent(t *testing.T) {
+	// parsed up front since mustParse may stop the test, which can't be done from another goroutine
+	urls := make([]*URL, 1000)
+	for i := range urls {
+		urls[i] = mustParse(t, "https://example.com/item/"+strconv.Itoa(i))
+	}
+
+	s := NewURLSet(FingerprintOptions{})
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	added := 0
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := 0
+			for _, u := range urls {
+				if s.Add(u) {
+					n++
+				}
+				assert.True(t, s.Contains(u))
+			}
+			mu.Lock()
+			added += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1000, added)
+	assert.Equal(t, 1000, s.Len())
+}
+
+func TestURLSetSerialization(t *testing.T) {
+	s := NewURLSet(FingerprintOptions{IgnoreQueryOrder: true})
+	for i := 0; i < 100; i++ {
+		s.Add(mustParse(t, "https://example.com/?a="+strconv.Itoa(i)+"&b=1"))
+	}
+
+	var buf bytes.Buffer
+	n, err := s.WriteTo(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(17+100*16), n)
+	data := buf.Bytes()
+
+	loaded, err := LoadURLSet(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, s.Options(), loaded.Options())
+	assert.Equal(t, 100, loaded.Len())
+	assert.True(t, loaded.Contains(mustParse(t, "https://example.com/?b=1&a=42")))
+
+	t.Run("Options mismatch", func(t *testing.T) {
+		_, err := NewURLSet(FingerprintOptions{}).ReadFrom(bytes.NewReader(data))
+		assert.Error(t, err)
+	})
+
+	t.Run("Corrupt", func(t *testing.T) {
+		_, err := LoadURLSet(bytes.NewReader([]byte("not a set file")))
+		assert.Error(t, err)
+		_, err = LoadURLSet(bytes.NewReader(data[:len(data)-3]))
+		assert.Error(t, err)
+	})
+}